@@ -1,187 +1,76 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
-	"math"
-	"strconv"
-	"strings"
-	"sync"
-)
-
-/// run the program, reading inputs from the input chan, and sending outputs to the output chan.
-/// if the waitgroup is not null then decrement the waitgroup counter upon program termination
-func runProgram(instructions []int, input chan int, output chan int, wg *sync.WaitGroup) {
-
-	if wg != nil {
-		defer wg.Done()
-	}
 
-	program := make([]int, len(instructions))
-	copy(program, instructions)
-	pc := 0
-
-	for {
-		opcode := program[pc]%10 + program[pc]/10%10*10
-		params := make([]int, 2)
-
-		numOperands := 0
-		switch opcode {
-		case 1, 2, 5, 6, 7, 8:
-			numOperands = 2
-		case 4:
-			numOperands = 1
-		}
+	"github.com/nathanshort/adventofcode2019/intcode"
+)
 
-		for i := 0; i < numOperands; i++ {
-			mode := (program[pc] / (10 * int(math.Pow(10, float64(i+1))))) % 10
-			if mode == 0 {
-				// position mode
-				params[i] = program[program[pc+i+1]]
-			} else {
-				// immediate mode
-				params[i] = program[pc+i+1]
-			}
-		}
+const numAmplifiers = 5
 
-		switch opcode {
+/// run one amplifier per phase setting, named A, B, C ..., either chained in
+/// order or with the last feeding back into the first.  amp A is seeded with 0.
+/// returns the last signal sent by the final amp
+func runAmplifiers(program []int64, setting []int64, feedback bool) (int64, error) {
 
-		case 1:
-			program[program[pc+3]] = params[0] + params[1]
-			pc += 4
-		case 2:
-			program[program[pc+3]] = params[0] * params[1]
-			pc += 4
-		case 3:
-			program[program[pc+1]] = <-input
-			pc += 2
-		case 4:
-			output <- params[0]
-			pc += 2
-		case 5:
-			if params[0] != 0 {
-				pc = params[1]
-			} else {
-				pc += 3
-			}
-		case 6:
-			if params[0] == 0 {
-				pc = params[1]
-			} else {
-				pc += 3
-			}
-		case 7:
-			toStore := 0
-			if params[0] < params[1] {
-				toStore = 1
-			}
-			program[program[pc+3]] = toStore
-			pc += 4
-		case 8:
-			toStore := 0
-			if params[0] == params[1] {
-				toStore = 1
-			}
-			program[program[pc+3]] = toStore
-			pc += 4
-		case 99:
-			return
-		default:
-			log.Fatalf("unknown opcode pc(%d) program(%v)", pc, program)
+	topology := intcode.NewTopology()
+	var names []string
+	for i, phase := range setting {
+		name := string(rune('A' + i))
+		if err := topology.AddNode(name, program, phase); err != nil {
+			return 0, err
 		}
+		names = append(names, name)
+	}
+	connect := topology.Chain
+	if feedback {
+		connect = topology.Ring
+	}
+	if err := connect(names...); err != nil {
+		return 0, err
+	}
+	if err := topology.Input(names[0], 0); err != nil {
+		return 0, err
 	}
-}
 
-/// find all 5 digit numbers with
-/// 1) all unique digits
-/// 2) no digits < minDigit
-/// 3) no digits > maxDigit
-func uniquePhaseSettings(minDigit int, maxDigit int) [][]int {
-	var unique [][]int
-	for attempt := 0; attempt < 100000; attempt++ {
-		var digits []int
-		digitsSeen := make(map[int]bool)
-		allUnique := true
-		for i := 4; i >= 0; i-- {
-			digit := attempt / int(math.Pow(10, float64(i))) % 10
-			if _, ok := digitsSeen[digit]; digit > maxDigit || digit < minDigit || ok != false {
-				allUnique = false
-				break
-			}
-			digitsSeen[digit] = true
-			digits = append(digits, digit)
-		}
-		if allUnique {
-			unique = append(unique, digits)
-		}
+	result, err := topology.Run(context.Background())
+	if err != nil {
+		return 0, err
 	}
-	return unique
+	last, _ := result.Node(names[len(names)-1])
+	return last.Last, nil
 }
 
-func part1(program []int) {
-	const numAmplifiers = 5
-	maxOutput := 0
-	for _, setting := range uniquePhaseSettings(0, 4) {
-		lastOutput := 0
-		for i := 0; i < numAmplifiers; i++ {
-			input := make(chan int, 2)
-			input <- setting[i]
-			input <- lastOutput
-			output := make(chan int, 1)
-			runProgram(program, input, output, nil)
-			lastOutput = <-output
-		}
-		if lastOutput > maxOutput {
-			maxOutput = lastOutput
-		}
+/// try every phase setting made of distinct digits from minDigit to maxDigit
+/// and return the one giving the largest signal
+func bestSetting(program []int64, minDigit int64, maxDigit int64, feedback bool) ([]int64, int64) {
+	candidates := intcode.Permutations(minDigit, maxDigit, numAmplifiers)
+	setting, signal, err := intcode.MaxSignal(candidates, func(setting []int64) (int64, error) {
+		return runAmplifiers(program, setting, feedback)
+	})
+	if err != nil {
+		log.Fatal(err)
 	}
-	fmt.Printf("part 1 max output: %d\n", maxOutput)
+	return setting, signal
 }
 
-func part2(program []int) {
-
-	largest := 0
-	numAmplifiers := 5
-
-	for _, setting := range uniquePhaseSettings(5, 9) {
-
-		/// setup pipeline whereby the output of one amplifier
-		/// is the input of the next amp.  feedback the last amp
-		/// into the first
-		var channels []chan int
-		for i := 0; i < numAmplifiers; i++ {
-			channel := make(chan int, 2)
-			channel <- setting[i]
-			channels = append(channels, channel)
-		}
-		/// seed amp A with the initial input
-		channels[0] <- 0
-
-		var wg sync.WaitGroup
-		wg.Add(numAmplifiers)
-		for i := 0; i < numAmplifiers; i++ {
-			go runProgram(program, channels[i], channels[(i+1)%numAmplifiers], &wg)
-		}
-		wg.Wait()
-
-		/// pull the result from the output of the last amp
-		result := <-channels[0]
-		if result > largest {
-			largest = result
-		}
-	}
+func part1(program []int64) {
+	setting, maxOutput := bestSetting(program, 0, 4, false)
+	fmt.Printf("part 1 max output: %d, phase setting %v\n", maxOutput, setting)
+}
 
-	fmt.Printf("part 2 max output: %d\n", largest)
+func part2(program []int64) {
+	setting, largest := bestSetting(program, 5, 9, true)
+	fmt.Printf("part 2 max output: %d, phase setting %v\n", largest, setting)
 }
 
 func main() {
 
-	input := "3,8,1001,8,10,8,105,1,0,0,21,38,63,76,93,118,199,280,361,442,99999,3,9,101,3,9,9,102,3,9,9,101,4,9,9,4,9,99,3,9,1002,9,2,9,101,5,9,9,1002,9,5,9,101,5,9,9,1002,9,4,9,4,9,99,3,9,101,2,9,9,102,3,9,9,4,9,99,3,9,101,2,9,9,102,5,9,9,1001,9,5,9,4,9,99,3,9,102,4,9,9,1001,9,3,9,1002,9,5,9,101,2,9,9,1002,9,2,9,4,9,99,3,9,1002,9,2,9,4,9,3,9,1001,9,1,9,4,9,3,9,1001,9,1,9,4,9,3,9,1001,9,1,9,4,9,3,9,1001,9,2,9,4,9,3,9,1002,9,2,9,4,9,3,9,101,2,9,9,4,9,3,9,1002,9,2,9,4,9,3,9,1001,9,1,9,4,9,3,9,101,2,9,9,4,9,99,3,9,102,2,9,9,4,9,3,9,1002,9,2,9,4,9,3,9,1001,9,2,9,4,9,3,9,102,2,9,9,4,9,3,9,101,1,9,9,4,9,3,9,102,2,9,9,4,9,3,9,102,2,9,9,4,9,3,9,1001,9,1,9,4,9,3,9,102,2,9,9,4,9,3,9,1001,9,1,9,4,9,99,3,9,101,1,9,9,4,9,3,9,101,2,9,9,4,9,3,9,1002,9,2,9,4,9,3,9,101,2,9,9,4,9,3,9,1001,9,2,9,4,9,3,9,1002,9,2,9,4,9,3,9,1002,9,2,9,4,9,3,9,102,2,9,9,4,9,3,9,1001,9,1,9,4,9,3,9,1002,9,2,9,4,9,99,3,9,1001,9,1,9,4,9,3,9,102,2,9,9,4,9,3,9,102,2,9,9,4,9,3,9,1002,9,2,9,4,9,3,9,1001,9,2,9,4,9,3,9,102,2,9,9,4,9,3,9,101,2,9,9,4,9,3,9,1002,9,2,9,4,9,3,9,101,1,9,9,4,9,3,9,1001,9,2,9,4,9,99,3,9,1002,9,2,9,4,9,3,9,102,2,9,9,4,9,3,9,101,2,9,9,4,9,3,9,101,1,9,9,4,9,3,9,1002,9,2,9,4,9,3,9,1001,9,2,9,4,9,3,9,102,2,9,9,4,9,3,9,101,1,9,9,4,9,3,9,101,2,9,9,4,9,3,9,1002,9,2,9,4,9,99"
-
-	var program []int
-	for _, value := range strings.Split(input, ",") {
-		asInt, _ := strconv.Atoi(value)
-		program = append(program, asInt)
+	program, err := intcode.LoadLocal("input")
+	if err != nil {
+		log.Fatal(err)
 	}
 
 	part1(program)
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/nathanshort/adventofcode2019/intcode"
+)
+
+// run a text based program attached to the terminal.  each line typed is sent
+// when the program asks for input
+func ascii(args []string) error {
+	flags := flag.NewFlagSet("ascii", flag.ExitOnError)
+	script := flags.String("script", "", "file of lines to send before reading stdin")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		return fmt.Errorf("ascii needs a program file, as stdin is used for input")
+	}
+	program, err := intcode.LoadProgram(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	var in io.Reader = os.Stdin
+	if *script != "" {
+		f, err := os.Open(*script)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = io.MultiReader(f, os.Stdin)
+	}
+
+	terminal := intcode.NewTerminal(intcode.NewFromProgram(program), os.Stdout)
+	return terminal.Interact(context.Background(), in, func(value int64) {
+		fmt.Printf("\nresult: %d\n", value)
+	})
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/nathanshort/adventofcode2019/intcode"
+)
+
+// assemble source into a comma separated program
+func asm(args []string) error {
+	flags := flag.NewFlagSet("asm", flag.ExitOnError)
+	flags.Parse(args)
+
+	var source io.Reader = os.Stdin
+	if path := flags.Arg(0); path != "" && path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		source = f
+	}
+
+	program, err := intcode.Assemble(source)
+	if err != nil {
+		return err
+	}
+	fmt.Println(intcode.Format(program))
+	return nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+	"io"
+
+	"github.com/nathanshort/adventofcode2019/intcode"
+)
+
+// write the control flow graph of a program as graphviz dot, or json with -json
+func cfg(args []string) error {
+	flags := flag.NewFlagSet("cfg", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "write json instead of dot")
+	out := flags.String("o", "", "file to write, stdout if not given")
+	flags.Parse(args)
+
+	program, err := intcode.LoadProgram(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	g := intcode.Analyze(program)
+	return writeTo(*out, func(w io.Writer) error {
+		if *asJSON {
+			return g.WriteJSON(w)
+		}
+		return g.WriteDOT(w)
+	})
+}
+
+// write a program as structured pseudo-Go
+func decompile(args []string) error {
+	flags := flag.NewFlagSet("decompile", flag.ExitOnError)
+	out := flags.String("o", "", "file to write, stdout if not given")
+	flags.Parse(args)
+
+	program, err := intcode.LoadProgram(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	return writeTo(*out, func(w io.Writer) error {
+		return intcode.Decompile(w, program)
+	})
+}
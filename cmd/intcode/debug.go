@@ -0,0 +1,437 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nathanshort/adventofcode2019/intcode"
+)
+
+const debugHelp = `commands:
+  s, step [n]            execute n instructions (default 1)
+  c, continue            run until a breakpoint, watchpoint, halt, error or input is needed
+  b, break <addr>        break when pc reaches addr
+  b, break op <op>       break before any instruction with opcode or mnemonic op
+  w, watch <addr>        break after any instruction that changes the value at addr
+  d, delete <addr>       remove the breakpoint or watchpoint on addr
+  d, delete op <op>      remove the opcode breakpoint on op
+  i, info                list breakpoints and watchpoints
+  r, regs                show pc, relative base, the next instruction and queued io
+  bt, history            list the most recently executed instructions
+  l, list [addr] [n]     disassemble n instructions from addr (default pc)
+  x <addr> [n]           dump n words of memory from addr.  addr may be rb+n
+  set <addr> <value>     store value at addr
+  in <value>...          queue input values
+  ascii <text>           queue text, followed by a newline, as input
+  h, help                show this help
+  q, quit                exit
+output values are printed as the program produces them.  ^C stops a continue
+an empty line repeats the previous command`
+
+// debugger drives a computer one instruction at a time so that it can stop
+// on breakpoints and watchpoints between instructions
+type debugger struct {
+	computer    *intcode.Computer
+	out         io.Writer
+	breakpoints map[int64]bool
+	opBreaks    map[int64]bool
+	watches     map[int64]int64
+	halted      bool
+	interrupt   chan os.Signal
+}
+
+func newDebugger(computer *intcode.Computer, out io.Writer) *debugger {
+	return &debugger{
+		computer:    computer,
+		out:         out,
+		breakpoints: make(map[int64]bool),
+		opBreaks:    make(map[int64]bool),
+		watches:     make(map[int64]int64),
+		interrupt:   make(chan os.Signal, 1),
+	}
+}
+
+// start an interactive debugging session on a program, or on the state a
+// program faulted in with -core
+func debug(args []string) error {
+	flags := flag.NewFlagSet("debug", flag.ExitOnError)
+	inputs := flags.String("in", "", "comma separated values to queue as input")
+	corePath := flags.String("core", "", "inspect this core file instead of starting a program")
+	flags.Parse(args)
+
+	if *corePath != "" {
+		core, err := intcode.LoadCore(*corePath)
+		if err != nil {
+			return err
+		}
+		if core.Fault != "" {
+			fmt.Printf("fault: %s\n", core.Fault)
+		}
+		d := newDebugger(core.Computer(), os.Stdout)
+		d.repl(os.Stdin)
+		return nil
+	}
+
+	if flags.NArg() != 1 {
+		return fmt.Errorf("debug needs a program file, as stdin is used for commands")
+	}
+	program, err := intcode.LoadProgram(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	values, err := parseInputs(*inputs)
+	if err != nil {
+		return err
+	}
+	computer := intcode.NewFromProgram(program)
+	computer.QueueInput(values...)
+	computer.KeepHistory(coreHistory)
+	d := newDebugger(computer, os.Stdout)
+	d.repl(os.Stdin)
+	return nil
+}
+
+func (d *debugger) repl(in io.Reader) {
+	scanner := bufio.NewScanner(in)
+	var last []string
+
+	d.showNext()
+	for {
+		fmt.Fprint(d.out, "(icdb) ")
+		if !scanner.Scan() {
+			fmt.Fprintln(d.out)
+			return
+		}
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			fields = last
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		last = fields
+
+		if fields[0] == "q" || fields[0] == "quit" {
+			return
+		}
+		if err := d.command(fields[0], fields[1:]); err != nil {
+			fmt.Fprintf(d.out, "error: %v\n", err)
+		}
+	}
+}
+
+func (d *debugger) command(name string, args []string) error {
+	switch name {
+	case "s", "step":
+		count := int64(1)
+		if len(args) > 0 {
+			n, err := strconv.ParseInt(args[0], 10, 64)
+			if err != nil {
+				return err
+			}
+			count = n
+		}
+		d.step(count)
+	case "c", "continue":
+		d.cont()
+	case "b", "break":
+		return d.setBreak(args, true)
+	case "d", "delete":
+		return d.setBreak(args, false)
+	case "w", "watch":
+		if len(args) != 1 {
+			return fmt.Errorf("watch needs an address")
+		}
+		address, err := d.address(args[0])
+		if err != nil {
+			return err
+		}
+		d.watches[address] = d.computer.Memory(address)
+	case "i", "info":
+		d.info()
+	case "r", "regs":
+		d.regs()
+	case "bt", "history":
+		d.history()
+	case "l", "list":
+		return d.list(args)
+	case "x":
+		return d.dump(args)
+	case "set":
+		if len(args) != 2 {
+			return fmt.Errorf("set needs an address and a value")
+		}
+		address, err := d.address(args[0])
+		if err != nil {
+			return err
+		}
+		value, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return err
+		}
+		d.computer.SetMemory(address, value)
+	case "in":
+		for _, arg := range args {
+			value, err := strconv.ParseInt(arg, 10, 64)
+			if err != nil {
+				return err
+			}
+			d.computer.QueueInput(value)
+		}
+	case "ascii":
+		for _, c := range strings.Join(args, " ") + "\n" {
+			d.computer.QueueInput(int64(c))
+		}
+	case "h", "help":
+		fmt.Fprintln(d.out, debugHelp)
+	default:
+		return fmt.Errorf("unknown command %q, try help", name)
+	}
+	return nil
+}
+
+// address parses a plain address, or rb+n / rb-n relative to the relative base
+func (d *debugger) address(text string) (int64, error) {
+	base := int64(0)
+	for _, prefix := range []string{"rb", "r"} {
+		if strings.HasPrefix(text, prefix) {
+			base = d.computer.RelativeBase()
+			text = strings.TrimPrefix(text, prefix)
+			if text == "" {
+				return base, nil
+			}
+			break
+		}
+	}
+	offset, err := strconv.ParseInt(text, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("bad address %q", text)
+	}
+	return base + offset, nil
+}
+
+func parseOpcode(text string) (int64, error) {
+	if op, ok := intcode.LookupMnemonic(strings.ToUpper(text)); ok {
+		return op.Opcode, nil
+	}
+	opcode, err := strconv.ParseInt(text, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("unknown opcode %q", text)
+	}
+	return opcode, nil
+}
+
+func (d *debugger) setBreak(args []string, set bool) error {
+	if len(args) == 2 && args[0] == "op" {
+		opcode, err := parseOpcode(args[1])
+		if err != nil {
+			return err
+		}
+		if set {
+			d.opBreaks[opcode] = true
+		} else {
+			delete(d.opBreaks, opcode)
+		}
+		return nil
+	}
+	if len(args) != 1 {
+		return fmt.Errorf("expected an address or op <opcode>")
+	}
+	address, err := d.address(args[0])
+	if err != nil {
+		return err
+	}
+	if set {
+		d.breakpoints[address] = true
+	} else {
+		delete(d.breakpoints, address)
+		delete(d.watches, address)
+	}
+	return nil
+}
+
+// execute executes one instruction.  it returns a reason to stop, or "" to keep going
+func (d *debugger) execute() string {
+	if d.halted {
+		return "program has halted"
+	}
+
+	pc := d.computer.PC()
+	status, err := d.computer.Step()
+	switch status {
+	case intcode.Halted:
+		d.halted = true
+		return "halted"
+	case intcode.NeedsInput:
+		return fmt.Sprintf("pc(%d) needs input, queue some with in or ascii", pc)
+	case intcode.Error:
+		return fmt.Sprintf("fault: %v", err)
+	case intcode.ProducedOutput:
+		for _, value := range d.computer.TakeOutputs() {
+			fmt.Fprintf(d.out, "output: %d\n", value)
+		}
+	}
+
+	var changed []string
+	for address, old := range d.watches {
+		if value := d.computer.Memory(address); value != old {
+			d.watches[address] = value
+			changed = append(changed, fmt.Sprintf("watch [%d]: %d -> %d (pc %d)", address, old, value, pc))
+		}
+	}
+	if len(changed) != 0 {
+		sort.Strings(changed)
+		return strings.Join(changed, "\n")
+	}
+	return ""
+}
+
+func (d *debugger) step(count int64) {
+	for i := int64(0); i < count; i++ {
+		fmt.Fprintf(d.out, "%6d  %s\n", d.computer.PC(), d.computer.Disassemble(d.computer.PC()))
+		if reason := d.execute(); reason != "" {
+			fmt.Fprintln(d.out, reason)
+			break
+		}
+	}
+	d.showNext()
+}
+
+func (d *debugger) cont() {
+	// a program that never reaches a breakpoint can only be stopped by hand
+	signal.Notify(d.interrupt, os.Interrupt)
+	defer signal.Stop(d.interrupt)
+
+	// always execute the first instruction, so that continuing from a
+	// breakpoint does not stop on it again
+	first := true
+	for {
+		select {
+		case <-d.interrupt:
+			fmt.Fprintln(d.out, "interrupted")
+			d.showNext()
+			return
+		default:
+		}
+		if !first {
+			pc := d.computer.PC()
+			if d.breakpoints[pc] {
+				fmt.Fprintf(d.out, "breakpoint at %d\n", pc)
+				break
+			}
+			if opcode := intcode.Decode(d.computer.Memory(pc)).Opcode; d.opBreaks[opcode] {
+				fmt.Fprintf(d.out, "opcode %d breakpoint at %d\n", opcode, pc)
+				break
+			}
+		}
+		first = false
+		if reason := d.execute(); reason != "" {
+			fmt.Fprintln(d.out, reason)
+			break
+		}
+	}
+	d.showNext()
+}
+
+func (d *debugger) showNext() {
+	pc := d.computer.PC()
+	fmt.Fprintf(d.out, "=> %6d  %s\n", pc, d.computer.Disassemble(pc))
+}
+
+func sortedAddresses(addresses map[int64]bool) []int64 {
+	var sorted []int64
+	for address := range addresses {
+		sorted = append(sorted, address)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted
+}
+
+func (d *debugger) info() {
+	for _, address := range sortedAddresses(d.breakpoints) {
+		fmt.Fprintf(d.out, "break %d\n", address)
+	}
+	for _, opcode := range sortedAddresses(d.opBreaks) {
+		fmt.Fprintf(d.out, "break op %d\n", opcode)
+	}
+	watched := make(map[int64]bool)
+	for address := range d.watches {
+		watched[address] = true
+	}
+	for _, address := range sortedAddresses(watched) {
+		fmt.Fprintf(d.out, "watch %d = %d\n", address, d.watches[address])
+	}
+}
+
+func (d *debugger) regs() {
+	fmt.Fprintf(d.out, "pc %d  rb %d\n", d.computer.PC(), d.computer.RelativeBase())
+	fmt.Fprintf(d.out, "pending input %v\n", d.computer.PendingInput())
+	d.showNext()
+}
+
+func (d *debugger) history() {
+	for _, event := range d.computer.History() {
+		fmt.Fprintf(d.out, "%10d %6d  %s\n", event.Step, event.PC, intcode.FormatEvent(event))
+	}
+}
+
+func (d *debugger) list(args []string) error {
+	address, count := d.computer.PC(), int64(10)
+	if len(args) > 0 {
+		a, err := d.address(args[0])
+		if err != nil {
+			return err
+		}
+		address = a
+	}
+	if len(args) > 1 {
+		n, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return err
+		}
+		count = n
+	}
+	for i := int64(0); i < count; i++ {
+		line := d.computer.Disassemble(address)
+		marker := "  "
+		if address == d.computer.PC() {
+			marker = "=>"
+		}
+		fmt.Fprintf(d.out, "%s %6d  %s\n", marker, address, line)
+		address += int64(len(line.Words))
+	}
+	return nil
+}
+
+func (d *debugger) dump(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("x needs an address")
+	}
+	address, err := d.address(args[0])
+	if err != nil {
+		return err
+	}
+	count := int64(8)
+	if len(args) > 1 {
+		if count, err = strconv.ParseInt(args[1], 10, 64); err != nil {
+			return err
+		}
+	}
+	const perRow = 8
+	for row := int64(0); row < count; row += perRow {
+		fmt.Fprintf(d.out, "%6d:", address+row)
+		for i := row; i < row+perRow && i < count; i++ {
+			fmt.Fprintf(d.out, " %d", d.computer.Memory(address+i))
+		}
+		fmt.Fprintln(d.out)
+	}
+	return nil
+}
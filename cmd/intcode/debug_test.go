@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/nathanshort/adventofcode2019/intcode"
+)
+
+func TestDebugger(t *testing.T) {
+	// output the input doubled, then halt
+	computer := intcode.New("3,20,1002,20,2,21,4,21,99")
+	var out bytes.Buffer
+	d := newDebugger(computer, &out)
+
+	d.repl(strings.NewReader(strings.Join([]string{
+		"b 6",
+		"c",
+		"in 21",
+		"c",
+		"x 20 2",
+		"w 21",
+		"info",
+		"bogus",
+		"s",
+		"",
+		"c",
+		"q",
+		"never read",
+	}, "\n")))
+
+	for _, want := range []string{
+		"pc(0) needs input",
+		"breakpoint at 6",
+		"    20: 21 42\n",
+		"break 6\nwatch 21 = 42\n",
+		"output: 42\n",
+		`error: unknown command "bogus", try help`,
+		"halted\n",
+		"program has halted\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("session output is missing %q:\n%s", want, out.String())
+		}
+	}
+	if !strings.HasSuffix(out.String(), "(icdb) ") {
+		t.Errorf("session did not stop at quit:\n%s", out.String())
+	}
+}
+
+func TestDebuggerInterrupt(t *testing.T) {
+	// loop forever
+	computer := intcode.New("1105,1,0")
+	var out bytes.Buffer
+	d := newDebugger(computer, &out)
+
+	d.interrupt <- os.Interrupt
+	d.repl(strings.NewReader("c\nq\n"))
+	if !strings.Contains(out.String(), "interrupted\n") {
+		t.Errorf("continue was not interrupted:\n%s", out.String())
+	}
+}
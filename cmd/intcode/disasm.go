@@ -0,0 +1,34 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	"github.com/nathanshort/adventofcode2019/intcode"
+)
+
+// print an annotated listing of a program, or assembler source with -asm.
+// with -core, report on a core written when a program faulted
+func disasm(args []string) error {
+	flags := flag.NewFlagSet("disasm", flag.ExitOnError)
+	source := flags.Bool("asm", false, "write source that intcode asm reassembles")
+	core := flags.String("core", "", "report on this core file instead of a program")
+	flags.Parse(args)
+
+	if *core != "" {
+		c, err := intcode.LoadCore(*core)
+		if err != nil {
+			return err
+		}
+		return c.WriteReport(os.Stdout)
+	}
+
+	program, err := intcode.LoadProgram(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if *source {
+		return intcode.WriteSource(os.Stdout, program)
+	}
+	return intcode.WriteListing(os.Stdout, program)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+
+	"github.com/nathanshort/adventofcode2019/intcode"
+)
+
+// a subcommand receives the arguments following its name
+type command struct {
+	usage string
+	run   func(args []string) error
+}
+
+var commands = map[string]command{
+	"ascii":     {usage: "ascii [-script file] program", run: ascii},
+	"asm":       {usage: "asm [source]", run: asm},
+	"cfg":       {usage: "cfg [-json] [-o file] [program]", run: cfg},
+	"debug":     {usage: "debug [-in values] program | debug -core file", run: debug},
+	"decompile": {usage: "decompile [-o file] [program]", run: decompile},
+	"disasm":    {usage: "disasm [-asm] [program] | disasm -core file", run: disasm},
+	"pack":      {usage: "pack [-o image] [program]", run: pack},
+	"profile":   {usage: "profile [-in values] [-default value] [-html file] [-core file] program", run: profile},
+	"replay":    {usage: "replay program trace", run: replay},
+	"trace":     {usage: "trace [-in values] [-o file] [-core file] program", run: trace},
+	"unpack":    {usage: "unpack [-o file] [image]", run: unpack},
+}
+
+// how many of the last instructions executed are kept for cores and the
+// debugger's history
+const coreHistory = 32
+
+// parse the comma separated values given to an -in flag
+func parseInputs(text string) ([]int64, error) {
+	if text == "" {
+		return nil, nil
+	}
+	values, err := intcode.ParseProgram(text)
+	if err != nil {
+		return nil, fmt.Errorf("-in: %w", err)
+	}
+	return values, nil
+}
+
+func usage() {
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(os.Stderr, "usage:\n")
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  intcode %s\n", commands[name].usage)
+	}
+	os.Exit(2)
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("intcode: ")
+
+	if len(os.Args) < 2 {
+		usage()
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		usage()
+	}
+	if err := cmd.run(os.Args[2:]); err != nil {
+		log.Fatal(err)
+	}
+}
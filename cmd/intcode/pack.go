@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/nathanshort/adventofcode2019/intcode"
+)
+
+// create path, or use stdout if path is empty or "-", and call write with it
+func writeTo(path string, write func(w io.Writer) error) error {
+	if path == "" || path == "-" {
+		w := bufio.NewWriter(os.Stdout)
+		if err := write(w); err != nil {
+			return err
+		}
+		return w.Flush()
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	if err := write(w); err != nil {
+		f.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// convert a program to a binary image
+func pack(args []string) error {
+	flags := flag.NewFlagSet("pack", flag.ExitOnError)
+	out := flags.String("o", "", "image file to write, stdout if not given")
+	flags.Parse(args)
+
+	program, err := intcode.LoadProgram(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	return writeTo(*out, func(w io.Writer) error {
+		return intcode.WriteImage(w, program)
+	})
+}
+
+// convert a binary image back to comma separated text
+func unpack(args []string) error {
+	flags := flag.NewFlagSet("unpack", flag.ExitOnError)
+	out := flags.String("o", "", "text file to write, stdout if not given")
+	flags.Parse(args)
+
+	program, err := intcode.LoadProgram(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	return writeTo(*out, func(w io.Writer) error {
+		_, err := fmt.Fprintln(w, intcode.Format(program))
+		return err
+	})
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/nathanshort/adventofcode2019/intcode"
+)
+
+// profileInput supplies the -in values, then the -default value forever
+type profileInput struct {
+	values       intcode.Input
+	defaultValue *int64
+}
+
+func (p *profileInput) Read() (int64, error) {
+	value, err := p.values.Read()
+	if err != nil && p.defaultValue != nil {
+		return *p.defaultValue, nil
+	}
+	return value, err
+}
+
+// run a program and report execution counts, opcode and memory usage and coverage
+func profile(args []string) error {
+	flags := flag.NewFlagSet("profile", flag.ExitOnError)
+	inputs := flags.String("in", "", "comma separated input values")
+	defaultValue := flags.Int64("default", 0, "input value supplied once the -in values run out")
+	htmlPath := flags.String("html", "", "also write an html report to this file")
+	corePath := flags.String("core", "", "write a core to this file if the program faults")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		return fmt.Errorf("profile needs a program file")
+	}
+	program, err := intcode.LoadProgram(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	values, err := parseInputs(*inputs)
+	if err != nil {
+		return err
+	}
+	input := &profileInput{values: intcode.NewSliceInput(values...)}
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == "default" {
+			input.defaultValue = defaultValue
+		}
+	})
+
+	// program output goes to stderr so that it does not mix with the report
+	p := intcode.NewProfile(program)
+	computer := intcode.NewFromProgram(program)
+	computer.SetTracer(p)
+	computer.SetCoreFile(*corePath, coreHistory)
+	if err := computer.Run(context.Background(), input, intcode.NewLineOutput(os.Stderr)); err != nil {
+		fmt.Fprintf(os.Stderr, "program stopped: %v\n", err)
+	}
+
+	if err := p.WriteText(os.Stdout); err != nil {
+		return err
+	}
+	if *htmlPath == "" {
+		return nil
+	}
+	f, err := os.Create(*htmlPath)
+	if err != nil {
+		return err
+	}
+	if err := p.WriteHTML(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/nathanshort/adventofcode2019/intcode"
+)
+
+// run a program, writing a json lines trace of every instruction it executes
+func trace(args []string) error {
+	flags := flag.NewFlagSet("trace", flag.ExitOnError)
+	inputs := flags.String("in", "", "comma separated input values.  without it input is read from stdin, one number per line")
+	path := flags.String("o", "trace.jsonl", "trace file to write")
+	corePath := flags.String("core", "", "write a core to this file if the program faults")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		return fmt.Errorf("trace needs a program file")
+	}
+	program, err := intcode.LoadProgram(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	var input intcode.Input = intcode.NewLineInput(os.Stdin)
+	if *inputs != "" {
+		values, err := parseInputs(*inputs)
+		if err != nil {
+			return err
+		}
+		input = intcode.NewSliceInput(values...)
+	}
+
+	f, err := os.Create(*path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	tracer := intcode.NewJSONTracer(w)
+
+	computer := intcode.NewFromProgram(program)
+	computer.SetTracer(tracer)
+	computer.SetCoreFile(*corePath, coreHistory)
+	runErr := computer.Run(context.Background(), input, intcode.NewLineOutput(os.Stdout))
+
+	if err := tracer.Err(); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return runErr
+}
+
+// re-execute a trace and check the program repeats it exactly
+func replay(args []string) error {
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+	flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		return fmt.Errorf("replay needs a program file and a trace file")
+	}
+	program, err := intcode.LoadProgram(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	replayed, err := intcode.Replay(program, bufio.NewReader(f))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("replayed %d instructions, no divergence\n", replayed)
+	return nil
+}
@@ -0,0 +1,3 @@
+module github.com/nathanshort/adventofcode2019
+
+go 1.13
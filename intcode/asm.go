@@ -0,0 +1,271 @@
+package intcode
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Assemble translates assembler source into a program.  each line holds at
+// most one statement, optionally preceded by labels and followed by a comment:
+//
+//	loop:  ADD [rb+x], #1 -> [rb+x]   ; comments run to the end of the line
+//	       JNZ [flag], #loop
+//	       var x = 2                  ; names a relative base offset
+//	flag:  data 0, 1, loop
+//
+// instructions are a mnemonic from the opcode table (case insensitive) and
+// their parameters, with any write parameter either after "->" or simply the
+// last comma separated operand.  operands are #n for immediate, [n] for
+// position and [rb+n] or [r+n] for relative mode.  n may be a number, a label,
+// a var, or a sum of those.  labels name the address of the statement after
+// them and can be used before they are defined.  vars can be redefined, and
+// take the value of the most recent definition above their use
+func Assemble(r io.Reader) ([]int64, error) {
+	a := &assembler{labels: make(map[string]int64), vars: make(map[string]int64)}
+	if err := a.scan(r); err != nil {
+		return nil, err
+	}
+	return a.encode()
+}
+
+type statement struct {
+	line     int
+	mnemonic string
+	operands []string
+	address  int64
+}
+
+type assembler struct {
+	statements []statement
+	labels     map[string]int64
+	vars       map[string]int64
+}
+
+func isIdent(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		letter := r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+		digit := r >= '0' && r <= '9'
+		if !letter && !(digit && i > 0) {
+			return false
+		}
+	}
+	return name != "r" && name != "rb"
+}
+
+func splitOperands(text string) []string {
+	var operands []string
+	for _, part := range strings.Split(text, "->") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		for _, operand := range strings.Split(part, ",") {
+			operands = append(operands, strings.TrimSpace(operand))
+		}
+	}
+	return operands
+}
+
+// scan is the first pass.  it splits the source into statements and assigns
+// every label its address
+func (a *assembler) scan(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	address := int64(0)
+	for number := 1; scanner.Scan(); number++ {
+		text := scanner.Text()
+		if comment := strings.Index(text, ";"); comment != -1 {
+			text = text[:comment]
+		}
+		text = strings.TrimSpace(text)
+
+		for {
+			colon := strings.Index(text, ":")
+			if colon == -1 || !isIdent(strings.TrimSpace(text[:colon])) {
+				break
+			}
+			label := strings.TrimSpace(text[:colon])
+			if _, ok := a.labels[label]; ok {
+				return fmt.Errorf("line %d: label %q already defined", number, label)
+			}
+			a.labels[label] = address
+			text = strings.TrimSpace(text[colon+1:])
+		}
+		if text == "" {
+			continue
+		}
+
+		mnemonic, operands := text, ""
+		if space := strings.IndexAny(text, " \t"); space != -1 {
+			mnemonic, operands = text[:space], text[space+1:]
+		}
+		s := statement{line: number, mnemonic: strings.ToUpper(mnemonic), address: address}
+		s.operands = splitOperands(operands)
+
+		switch s.mnemonic {
+		case "VAR":
+		case "DATA":
+			if len(s.operands) == 0 {
+				return fmt.Errorf("line %d: data needs at least one value", number)
+			}
+			address += int64(len(s.operands))
+		default:
+			op, ok := LookupMnemonic(s.mnemonic)
+			if !ok {
+				return fmt.Errorf("line %d: unknown mnemonic %q", number, mnemonic)
+			}
+			if len(s.operands) != len(op.Params) {
+				return fmt.Errorf("line %d: %s takes %d operands, got %d", number, op.Mnemonic, len(op.Params), len(s.operands))
+			}
+			address += op.Size()
+		}
+		a.statements = append(a.statements, s)
+	}
+	return scanner.Err()
+}
+
+// encode is the second pass.  it defines vars in order and encodes every statement
+func (a *assembler) encode() ([]int64, error) {
+	var program []int64
+	for _, s := range a.statements {
+		switch s.mnemonic {
+		case "VAR":
+			if err := a.defineVar(s); err != nil {
+				return nil, err
+			}
+		case "DATA":
+			for _, operand := range s.operands {
+				value, err := a.eval(operand)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %v", s.line, err)
+				}
+				program = append(program, value)
+			}
+		default:
+			op, _ := LookupMnemonic(s.mnemonic)
+			modes := make([]Mode, len(op.Params))
+			values := make([]int64, len(op.Params))
+			for i, operand := range s.operands {
+				mode, value, err := a.operand(operand)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %v", s.line, err)
+				}
+				if op.Params[i] == Write && mode == Immediate {
+					return nil, fmt.Errorf("line %d: %s cannot write to immediate operand %q", s.line, op.Mnemonic, operand)
+				}
+				modes[i], values[i] = mode, value
+			}
+			program = append(program, Encode(op.Opcode, modes))
+			program = append(program, values...)
+		}
+	}
+	return program, nil
+}
+
+func (a *assembler) defineVar(s statement) error {
+	if len(s.operands) != 1 {
+		return fmt.Errorf("line %d: var needs a name and a value", s.line)
+	}
+	fields := strings.SplitN(s.operands[0], "=", 2)
+	name := strings.TrimSpace(fields[0])
+	if len(fields) != 2 || !isIdent(name) {
+		return fmt.Errorf("line %d: expected var name = value", s.line)
+	}
+	if _, ok := a.labels[name]; ok {
+		return fmt.Errorf("line %d: var %q is already a label", s.line, name)
+	}
+	value, err := a.eval(fields[1])
+	if err != nil {
+		return fmt.Errorf("line %d: %v", s.line, err)
+	}
+	a.vars[name] = value
+	return nil
+}
+
+// operand parses a mode marked operand
+func (a *assembler) operand(text string) (Mode, int64, error) {
+	if strings.HasPrefix(text, "#") {
+		value, err := a.eval(text[1:])
+		return Immediate, value, err
+	}
+	if !strings.HasPrefix(text, "[") || !strings.HasSuffix(text, "]") {
+		return 0, 0, fmt.Errorf("operand %q needs a mode: #n, [n] or [rb+n]", text)
+	}
+	inner := strings.TrimSpace(text[1 : len(text)-1])
+	for _, base := range []string{"rb", "r"} {
+		rest := strings.TrimPrefix(inner, base)
+		if rest == inner {
+			continue
+		}
+		rest = strings.TrimSpace(rest)
+		if rest == "" {
+			return Relative, 0, nil
+		}
+		if rest[0] == '+' || rest[0] == '-' {
+			value, err := a.eval(rest)
+			return Relative, value, err
+		}
+	}
+	value, err := a.eval(inner)
+	return Position, value, err
+}
+
+// eval evaluates a sum of numbers, labels and vars
+func (a *assembler) eval(expr string) (int64, error) {
+	rest := strings.TrimSpace(expr)
+	if rest == "" {
+		return 0, fmt.Errorf("missing value")
+	}
+	total := int64(0)
+	for rest != "" {
+		sign := int64(1)
+		for rest != "" && (rest[0] == '+' || rest[0] == '-') {
+			if rest[0] == '-' {
+				sign = -sign
+			}
+			rest = strings.TrimSpace(rest[1:])
+		}
+		end := strings.IndexAny(rest, "+-")
+		if end == -1 {
+			end = len(rest)
+		}
+		term := strings.TrimSpace(rest[:end])
+		rest = rest[end:]
+
+		// negative literals are parsed whole, as the most negative word has
+		// no positive counterpart
+		if sign < 0 {
+			if value, err := strconv.ParseInt("-"+term, 10, 64); err == nil {
+				total += value
+				continue
+			}
+		}
+		value, err := a.term(term)
+		if err != nil {
+			return 0, err
+		}
+		total += sign * value
+	}
+	return total, nil
+}
+
+func (a *assembler) term(term string) (int64, error) {
+	if value, err := strconv.ParseInt(term, 10, 64); err == nil {
+		return value, nil
+	}
+	if value, ok := a.vars[term]; ok {
+		return value, nil
+	}
+	if value, ok := a.labels[term]; ok {
+		return value, nil
+	}
+	if term == "" {
+		return 0, fmt.Errorf("missing value")
+	}
+	return 0, fmt.Errorf("undefined symbol %q", term)
+}
@@ -0,0 +1,28 @@
+package intcode
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestSourceRoundTrip(t *testing.T) {
+	for _, program := range [][]int64{
+		loadTestImage(t, "boost.txt"),
+		{1101, math.MinInt64, math.MaxInt64, 0, 99, math.MinInt64, math.MaxInt64, -1},
+		{2201, math.MinInt64, math.MaxInt64, 0, 99},
+	} {
+		var source bytes.Buffer
+		if err := WriteSource(&source, program); err != nil {
+			t.Fatal(err)
+		}
+		got, err := Assemble(&source)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, program) {
+			t.Errorf("round trip gave %v, want %v", got, program)
+		}
+	}
+}
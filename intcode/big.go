@@ -0,0 +1,373 @@
+package intcode
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/big"
+)
+
+// BigComputer is an intcode machine whose memory holds arbitrary precision
+// integers, for programs whose arithmetic does not fit in an int64.  addresses,
+// jump targets and the relative base must still fit in an int64.  it is slower
+// than Computer and is not traced
+type BigComputer struct {
+	pc           int64
+	relativeBase int64
+	memory       map[int64]*big.Int
+	inputs       []*big.Int
+	outputs      []*big.Int
+	executed     int64
+	halted       bool
+	limits       Limits
+}
+
+var bigZero = big.NewInt(0)
+
+// ParseBigProgram reads a comma separated program of arbitrary precision
+// words, accepting the same layout as ParseProgram
+func ParseBigProgram(text string) ([]*big.Int, error) {
+	var program []*big.Int
+	err := parseWords(text, func(token string) error {
+		word, ok := new(big.Int).SetString(token, 10)
+		if !ok {
+			return errors.New("invalid syntax")
+		}
+		program = append(program, word)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return program, nil
+}
+
+// ParseBig is ParseBigProgram for programs known to be well formed.  it
+// panics if the program is malformed
+func ParseBig(instructions string) []*big.Int {
+	program, err := ParseBigProgram(instructions)
+	if err != nil {
+		panic("intcode: ParseBig: " + err.Error())
+	}
+	return program
+}
+
+// NewBig returns a big computer loaded with the comma separated program.
+// like ParseBig, it panics if the program is malformed
+func NewBig(instructions string) *BigComputer {
+	return NewBigFromProgram(ParseBig(instructions))
+}
+
+// NewBigFromProgram returns a big computer loaded with a copy of program
+func NewBigFromProgram(program []*big.Int) *BigComputer {
+	c := &BigComputer{memory: make(map[int64]*big.Int, len(program))}
+	for address, word := range program {
+		c.SetMemory(int64(address), word)
+	}
+	return c
+}
+
+// Widen converts an int64 program for loading into a big computer
+func Widen(program []int64) []*big.Int {
+	wide := make([]*big.Int, len(program))
+	for i, word := range program {
+		wide[i] = big.NewInt(word)
+	}
+	return wide
+}
+
+// PC returns the address of the next instruction
+func (c *BigComputer) PC() int64 {
+	return c.pc
+}
+
+// RelativeBase returns the current relative base
+func (c *BigComputer) RelativeBase() int64 {
+	return c.relativeBase
+}
+
+// Executed returns the number of instructions executed so far
+func (c *BigComputer) Executed() int64 {
+	return c.executed
+}
+
+// SetLimits bounds the program, as Computer.SetLimits
+func (c *BigComputer) SetLimits(limits Limits) {
+	c.limits = limits
+}
+
+// Memory returns a copy of the value stored at address
+func (c *BigComputer) Memory(address int64) *big.Int {
+	return new(big.Int).Set(c.get(address))
+}
+
+// SetMemory stores a copy of value at address
+func (c *BigComputer) SetMemory(address int64, value *big.Int) {
+	c.memory[address] = new(big.Int).Set(value)
+}
+
+// get returns the value at address.  values in memory are never modified in
+// place, so the result must not be either
+func (c *BigComputer) get(address int64) *big.Int {
+	if value, ok := c.memory[address]; ok {
+		return value
+	}
+	return bigZero
+}
+
+func (c *BigComputer) fault(err error) *Fault {
+	return c.faultAt(c.pc, err)
+}
+
+func (c *BigComputer) faultAt(pc int64, err error) *Fault {
+	word := c.get(pc)
+	f := &Fault{Err: err, PC: pc, RelativeBase: c.relativeBase}
+	if word.IsInt64() {
+		f.Instruction = word.Int64()
+	}
+	return f
+}
+
+// small converts a value used as an address or offset
+func (c *BigComputer) small(value *big.Int) (int64, error) {
+	if !value.IsInt64() {
+		return 0, c.fault(ErrOverflow)
+	}
+	return value.Int64(), nil
+}
+
+// address returns the memory address referenced by the parameter at pcOffset
+func (c *BigComputer) address(inst Instruction, pcOffset int64) (int64, error) {
+	operand, err := c.small(c.get(c.pc + pcOffset))
+	if err != nil {
+		return 0, err
+	}
+	var address int64
+	switch inst.Modes[pcOffset-1] {
+	case Position:
+		address = operand
+	case Relative:
+		address = operand + c.relativeBase
+	default:
+		return 0, c.fault(ErrBadMode)
+	}
+	if address < 0 {
+		return 0, c.fault(ErrNegativeAddress)
+	}
+	if c.limits.MaxAddress > 0 && address > c.limits.MaxAddress {
+		return 0, c.fault(ErrMemoryLimit)
+	}
+	return address, nil
+}
+
+func (c *BigComputer) read(inst Instruction, pcOffset int64) (*big.Int, error) {
+	if inst.Modes[pcOffset-1] == Immediate {
+		return c.get(c.pc + pcOffset), nil
+	}
+	address, err := c.address(inst, pcOffset)
+	if err != nil {
+		return nil, err
+	}
+	return c.get(address), nil
+}
+
+func (c *BigComputer) write(inst Instruction, pcOffset int64, value *big.Int) error {
+	address, err := c.address(inst, pcOffset)
+	if err != nil {
+		return err
+	}
+	c.memory[address] = value
+	return nil
+}
+
+// QueueInput adds copies of values to the end of the input queue
+func (c *BigComputer) QueueInput(values ...*big.Int) {
+	for _, value := range values {
+		c.inputs = append(c.inputs, new(big.Int).Set(value))
+	}
+}
+
+// TakeOutput removes and returns the oldest queued output value
+func (c *BigComputer) TakeOutput() (*big.Int, bool) {
+	if len(c.outputs) == 0 {
+		return nil, false
+	}
+	value := c.outputs[0]
+	c.outputs = c.outputs[1:]
+	return value, true
+}
+
+// TakeOutputs removes and returns every queued output value
+func (c *BigComputer) TakeOutputs() []*big.Int {
+	outputs := c.outputs
+	c.outputs = nil
+	return outputs
+}
+
+// Step executes a single instruction, as Computer.Step
+func (c *BigComputer) Step() (Status, error) {
+	if c.halted {
+		return Halted, nil
+	}
+	status, err := c.step()
+	if status == NeedsInput || status == Error {
+		return status, err
+	}
+	if status == Halted {
+		c.halted = true
+	}
+	c.executed++
+	return status, err
+}
+
+// isStandard reports whether opcode is one of the opcodes every intcode
+// machine has, as opposed to a registered extension
+func isStandard(opcode int64) bool {
+	return opcode >= 1 && opcode <= 9 || opcode == 99
+}
+
+// step executes an instruction the way Computer does, loading read
+// parameters and storing write parameters as the op's description lays them
+// out, with big arithmetic in place of the op's handler
+func (c *BigComputer) step() (Status, error) {
+
+	if c.pc < 0 {
+		return Error, c.fault(ErrNegativeAddress)
+	}
+	if c.limits.MaxAddress > 0 && c.pc > c.limits.MaxAddress {
+		return Error, c.fault(ErrMemoryLimit)
+	}
+	if c.limits.MaxInstructions > 0 && c.executed >= c.limits.MaxInstructions {
+		return Error, c.fault(ErrInstructionLimit)
+	}
+
+	word := c.get(c.pc)
+	if !word.IsInt64() {
+		return Error, c.fault(ErrUnknownOpcode)
+	}
+	// registered extensions are written for Computer, so only the standard
+	// opcodes are run here
+	inst := Decode(word.Int64())
+	if !isStandard(inst.Opcode) {
+		return Error, c.fault(ErrUnknownOpcode)
+	}
+	op := standardOps[inst.Opcode]
+
+	var args [maxParams]*big.Int
+	for i := int64(0); i < op.Reads(); i++ {
+		value, err := c.read(inst, i+1)
+		if err != nil {
+			return Error, err
+		}
+		args[i] = value
+	}
+
+	status := Running
+	jumped, target := false, int64(0)
+	switch inst.Opcode {
+	case 1:
+		args[2] = new(big.Int).Add(args[0], args[1])
+	case 2:
+		args[2] = new(big.Int).Mul(args[0], args[1])
+	case 3:
+		if len(c.inputs) == 0 {
+			return NeedsInput, nil
+		}
+		args[0] = c.inputs[0]
+		c.inputs = c.inputs[1:]
+	case 4:
+		c.outputs = append(c.outputs, new(big.Int).Set(args[0]))
+		status = ProducedOutput
+	case 5, 6:
+		if (args[0].Sign() != 0) == (inst.Opcode == 5) {
+			address, err := c.small(args[1])
+			if err != nil {
+				return Error, err
+			}
+			jumped, target = true, address
+		}
+	case 7, 8:
+		cmp := args[0].Cmp(args[1])
+		args[2] = bigZero
+		if (inst.Opcode == 7 && cmp < 0) || (inst.Opcode == 8 && cmp == 0) {
+			args[2] = big.NewInt(1)
+		}
+	case 9:
+		offset, err := c.small(args[0])
+		if err != nil {
+			return Error, err
+		}
+		c.relativeBase += offset
+	case 99:
+		return Halted, nil
+	}
+
+	for i := op.Reads(); i < int64(len(op.Params)); i++ {
+		if err := c.write(inst, i+1, args[i]); err != nil {
+			return Error, err
+		}
+	}
+	if jumped {
+		c.pc = target
+	} else {
+		c.pc += op.Size()
+	}
+	return status, nil
+}
+
+// BigInput supplies values to a big computer.  Read returns io.EOF once no
+// more values will be supplied
+type BigInput interface {
+	Read() (*big.Int, error)
+}
+
+// BigOutput receives the values sent by a big computer
+type BigOutput interface {
+	Write(value *big.Int) error
+}
+
+// BigFuncInput calls the func whenever the program needs a value
+type BigFuncInput func() *big.Int
+
+// Read implements BigInput
+func (f BigFuncInput) Read() (*big.Int, error) {
+	return f(), nil
+}
+
+// BigFuncOutput calls the func with every value the program produces
+type BigFuncOutput func(value *big.Int)
+
+// Write implements BigOutput
+func (f BigFuncOutput) Write(value *big.Int) error {
+	f(value)
+	return nil
+}
+
+// Run runs the program until it halts or ctx is done, as Computer.Run.  a nil
+// input reads as closed and a nil output discards values
+func (c *BigComputer) Run(ctx context.Context, input BigInput, output BigOutput) error {
+
+	if closer, ok := output.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	read := func() error {
+		if input == nil {
+			return io.EOF
+		}
+		value, err := input.Read()
+		if err != nil {
+			return err
+		}
+		c.QueueInput(value)
+		return nil
+	}
+	write := func() error {
+		value, _ := c.TakeOutput()
+		if output == nil {
+			return nil
+		}
+		return output.Write(value)
+	}
+	return drive(ctx, c, read, write)
+}
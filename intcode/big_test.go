@@ -0,0 +1,106 @@
+package intcode
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestOverflowCheck(t *testing.T) {
+	for _, program := range []string{
+		"1101,9223372036854775807,1,0,99",
+		"1101,-9223372036854775808,-1,0,99",
+		"1102,4294967296,4294967296,0,99",
+		"1102,-9223372036854775808,-1,0,99",
+	} {
+		c := New(program)
+		if err := c.Run(context.Background(), nil, nil); err != nil {
+			t.Fatalf("%s: unchecked run failed: %v", program, err)
+		}
+		c = New(program)
+		c.SetOverflowCheck(true)
+		if err := c.Run(context.Background(), nil, nil); !errors.Is(err, ErrOverflow) {
+			t.Errorf("%s: checked run returned %v, want ErrOverflow", program, err)
+		}
+	}
+
+	c := New("1102,34915192,34915192,7,4,7,99,0")
+	c.SetOverflowCheck(true)
+	output := &SliceOutput{}
+	if err := c.Run(context.Background(), nil, output); err != nil || output.Values[0] != 1219070632396864 {
+		t.Errorf("got %v, %v, want 1219070632396864", output.Values, err)
+	}
+}
+
+func TestBigComputer(t *testing.T) {
+	// squares its input twice, well past the range of an int64
+	c := NewBig("3,0,2,0,0,0,2,0,0,0,4,0,99")
+	var got *big.Int
+	input := BigFuncInput(func() *big.Int { return big.NewInt(1 << 40) })
+	if err := c.Run(context.Background(), input, BigFuncOutput(func(value *big.Int) { got = value })); err != nil {
+		t.Fatal(err)
+	}
+	want := new(big.Int).Lsh(big.NewInt(1), 160)
+	if got.Cmp(want) != 0 {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	boost := NewBigFromProgram(Widen(loadTestImage(t, "boost.txt")))
+	boost.QueueInput(big.NewInt(1))
+	var outputs []*big.Int
+	if err := boost.Run(context.Background(), nil, BigFuncOutput(func(value *big.Int) { outputs = append(outputs, value) })); err != nil {
+		t.Fatal(err)
+	}
+	if len(outputs) != 1 {
+		t.Errorf("BOOST produced %v, want a single keycode", outputs)
+	}
+}
+
+func TestBigComputerExtensions(t *testing.T) {
+	defer registerExtensions(t)()
+	for _, program := range []string{"52,0,0,0,99", "50,0,99"} {
+		_, err := NewBig(program).Step()
+		if !errors.Is(err, ErrUnknownOpcode) {
+			t.Errorf("%s: got %v, want ErrUnknownOpcode", program, err)
+		}
+	}
+}
+
+func TestBigComputerOpcodes(t *testing.T) {
+	for _, test := range opcodeTests {
+		c := NewBig(test.program)
+		for _, value := range test.input {
+			c.QueueInput(big.NewInt(value))
+		}
+		var got []int64
+		err := c.Run(context.Background(), nil, BigFuncOutput(func(value *big.Int) { got = append(got, value.Int64()) }))
+		if err != nil || !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, %v, want %v", test.name, got, err, test.want)
+		}
+	}
+}
+
+func TestBigComputerStep(t *testing.T) {
+	c := NewBig("3,5,99")
+	if status, err := c.Step(); status != NeedsInput || err != nil || c.Executed() != 0 {
+		t.Fatalf("got %v, %v after %d instructions, want needs input", status, err, c.Executed())
+	}
+	c.QueueInput(big.NewInt(1))
+	for i := 0; i < 3; i++ {
+		c.Step()
+	}
+	if status, _ := c.Step(); status != Halted || c.Executed() != 2 || c.PC() != 2 {
+		t.Errorf("got %v at pc %d after %d instructions, want halted at 2 after 2", status, c.PC(), c.Executed())
+	}
+
+	c = NewBig("1105,1,0")
+	c.SetLimits(Limits{MaxInstructions: 10})
+	if err := c.Run(context.Background(), nil, nil); !errors.Is(err, ErrInstructionLimit) {
+		t.Errorf("got %v, want ErrInstructionLimit", err)
+	}
+	if err := NewBig("3,0,99").Run(context.Background(), nil, nil); !errors.Is(err, ErrInputClosed) {
+		t.Errorf("got %v, want ErrInputClosed", err)
+	}
+}
@@ -0,0 +1,482 @@
+package intcode
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// EdgeKind is how control passes along an edge of a control flow graph
+type EdgeKind uint8
+
+const (
+	// Fall edges run on to the next instruction
+	Fall EdgeKind = iota
+	// Jump edges are taken jumps, conditional or not
+	Jump
+	// Call edges go from a call site to the routine it calls
+	Call
+	// Return edges go from a call site to its return address, standing in
+	// for the path through the routine called
+	Return
+)
+
+var edgeKindNames = [...]string{Fall: "fall", Jump: "jump", Call: "call", Return: "return"}
+
+func (k EdgeKind) String() string {
+	return edgeKindNames[k]
+}
+
+// MarshalText names the kind in json
+func (k EdgeKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// Edge joins the block ending at From to the block starting at To
+type Edge struct {
+	From int64    `json:"from"`
+	To   int64    `json:"to"`
+	Kind EdgeKind `json:"kind"`
+}
+
+// Block is a basic block: instructions that always execute in sequence,
+// entered only at the first and left only after the last
+type Block struct {
+	Start int64
+	// End is the address after the last instruction
+	End   int64
+	Lines []Line
+	Succs []Edge
+	// Return is set when the block ends by jumping through the return
+	// address a caller left on the stack
+	Return bool
+	// Indirect is set when the block ends in a jump to a computed address
+	// that is not a return, so its successors are unknown
+	Indirect bool
+	// Undecodable is set when execution runs on into words that are not a
+	// valid instruction
+	Undecodable bool
+}
+
+// Last returns the block's final instruction
+func (b *Block) Last() Line {
+	return b.Lines[len(b.Lines)-1]
+}
+
+// Function is a routine entered by the call idiom: the caller stores the
+// return address at [r+0] and jumps, and the routine moves the relative base
+// up by its frame size, then back down before jumping through [r+0]
+type Function struct {
+	Entry int64 `json:"entry"`
+	// Frame is the relative base adjustment the routine starts with, or 0
+	Frame int64 `json:"frame"`
+	// Blocks holds the start of every block in the routine, not counting
+	// the routines it calls
+	Blocks []int64 `json:"blocks"`
+	// Returns holds the start of each block the routine returns from
+	Returns []int64 `json:"returns"`
+	// Callers holds the address of each call site
+	Callers []int64 `json:"callers"`
+}
+
+// CodeWrite is an instruction that stores into the code of the program,
+// found by the analysis.  only position mode writes are known statically
+type CodeWrite struct {
+	PC      int64 `json:"pc"`
+	Address int64 `json:"address"`
+	// Target is the address of the instruction that is modified.  for a
+	// write into the undecodable words a block runs on into, it is the
+	// address execution reaches them at
+	Target int64 `json:"target"`
+}
+
+// CFG is a control flow graph recovered from a program without running it.
+// code is found by following execution from address 0 through fall throughs
+// and jumps with immediate targets.  jumps to computed addresses are only
+// followed when they are recognised as returns from a call
+type CFG struct {
+	Blocks    []*Block
+	Edges     []Edge
+	Functions []*Function
+	// CodeWrites lists the self-modifying writes into code
+	CodeWrites []CodeWrite
+
+	blocks map[int64]*Block
+	// code maps each word of every decoded instruction to the address the
+	// instruction starts at
+	code map[int64]int64
+}
+
+// Block returns the block starting at address, or nil
+func (g *CFG) Block(address int64) *Block {
+	return g.blocks[address]
+}
+
+// Function returns the routine entered at address, or nil
+func (g *CFG) Function(address int64) *Function {
+	for _, f := range g.Functions {
+		if f.Entry == address {
+			return f
+		}
+	}
+	return nil
+}
+
+// IsCode reports whether address holds part of a decoded instruction
+func (g *CFG) IsCode(address int64) bool {
+	_, ok := g.code[address]
+	return ok
+}
+
+// flow is where control can go after one instruction
+type flow struct {
+	// next is set when execution can continue past the instruction
+	next bool
+	// target is set when the instruction can jump to an immediate address
+	target   bool
+	address  int64
+	call     bool
+	ret      bool
+	indirect bool
+}
+
+// isJump reports whether the instruction is one of the built in jumps
+func isJump(opcode int64) bool {
+	return opcode == 5 || opcode == 6
+}
+
+// flowOf works out where control goes after the instruction in line
+func flowOf(program []int64, line Line) flow {
+	inst := Decode(line.Words[0])
+	switch {
+	case inst.Opcode == 99:
+		return flow{}
+	case !isJump(inst.Opcode):
+		return flow{next: true}
+	}
+
+	f := flow{next: true, target: true, address: line.Words[2]}
+	if inst.Modes[0] == Immediate {
+		// the condition is known, so only one way out is possible
+		taken := (line.Words[1] != 0) == (inst.Opcode == 5)
+		f.next, f.target = !taken, taken
+	}
+	if !f.next {
+		ret, ok := returnAddress(program, line.Address)
+		f.call = ok && ret == line.Address+int64(len(line.Words))
+	}
+	if f.target && inst.Modes[1] != Immediate {
+		// a call through a computed address is still expected to come
+		// back, but where it goes is unknown
+		f.target = false
+		f.ret = !f.next && !f.call && inst.Modes[1] == Relative
+		f.indirect = !f.ret
+	}
+	return f
+}
+
+// returnAddress finds the return address stored at [r+0] by the instruction
+// before a jump at address, if it is one of the usual ADD #x, #y -> [r+0] or
+// MUL #x, #y -> [r+0] forms
+func returnAddress(program []int64, address int64) (int64, bool) {
+	if address < 4 {
+		return 0, false
+	}
+	line, ok := decodeAt(func(a int64) int64 { return program[a] }, address-4, address)
+	if !ok {
+		return 0, false
+	}
+	inst := Decode(line.Words[0])
+	if inst.Opcode != 1 && inst.Opcode != 2 {
+		return 0, false
+	}
+	if inst.Modes[0] != Immediate || inst.Modes[1] != Immediate || inst.Modes[2] != Relative || line.Words[3] != 0 {
+		return 0, false
+	}
+	if inst.Opcode == 1 {
+		return line.Words[1] + line.Words[2], true
+	}
+	return line.Words[1] * line.Words[2], true
+}
+
+// Analyze builds the control flow graph of program
+func Analyze(program []int64) *CFG {
+	fetch := func(address int64) int64 { return program[address] }
+	end := int64(len(program))
+
+	// find every reachable instruction, and the leaders that start blocks
+	lines := make(map[int64]Line)
+	flows := make(map[int64]flow)
+	leaders := map[int64]bool{0: true}
+	undecodable := make(map[int64]bool)
+	// every address is checked before it is decoded, so an empty program
+	// gives an empty graph
+	var work []int64
+	visit := func(address int64) {
+		if address >= 0 && address < end {
+			work = append(work, address)
+		}
+	}
+	visit(0)
+	for len(work) != 0 {
+		address := work[len(work)-1]
+		work = work[:len(work)-1]
+		if _, seen := lines[address]; seen || undecodable[address] {
+			continue
+		}
+		line, ok := decodeAt(fetch, address, end)
+		if !ok {
+			undecodable[address] = true
+			continue
+		}
+		lines[address] = line
+		f := flowOf(program, line)
+		flows[address] = f
+
+		next := address + int64(len(line.Words))
+		if f.next {
+			visit(next)
+		}
+		if f.target {
+			visit(f.address)
+			leaders[f.address] = true
+		}
+		if f.call {
+			// the routine called is expected to come back
+			visit(next)
+		}
+		if isJump(Decode(line.Words[0]).Opcode) || !f.next {
+			leaders[next] = true
+		}
+	}
+
+	g := &CFG{blocks: make(map[int64]*Block), code: make(map[int64]int64)}
+	for address, line := range lines {
+		for i := range line.Words {
+			g.code[address+int64(i)] = address
+		}
+	}
+
+	// cut the instructions into blocks
+	var starts []int64
+	for address := range leaders {
+		if _, ok := lines[address]; ok {
+			starts = append(starts, address)
+		}
+	}
+	sortAddresses(starts)
+	for _, start := range starts {
+		block := &Block{Start: start}
+		address := start
+		for {
+			line := lines[address]
+			block.Lines = append(block.Lines, line)
+			address += int64(len(line.Words))
+			f := flows[line.Address]
+			if isJump(Decode(line.Words[0]).Opcode) || !f.next {
+				break
+			}
+			if _, ok := lines[address]; !ok || leaders[address] {
+				break
+			}
+		}
+		block.End = address
+		block.Undecodable = flows[block.Last().Address].next && undecodable[address]
+		g.Blocks = append(g.Blocks, block)
+		g.blocks[start] = block
+	}
+
+	// join them up
+	callers := make(map[int64][]int64)
+	for _, block := range g.Blocks {
+		last := block.Last()
+		f := flows[last.Address]
+		block.Return, block.Indirect = f.ret, f.indirect
+		if f.next && g.blocks[block.End] != nil {
+			block.Succs = append(block.Succs, Edge{From: block.Start, To: block.End, Kind: Fall})
+		}
+		if f.target && g.blocks[f.address] != nil {
+			kind := Jump
+			if f.call {
+				kind = Call
+				callers[f.address] = append(callers[f.address], last.Address)
+			}
+			block.Succs = append(block.Succs, Edge{From: block.Start, To: f.address, Kind: kind})
+		}
+		if f.call && g.blocks[block.End] != nil {
+			// an indirect call has no call edge, but still returns
+			block.Succs = append(block.Succs, Edge{From: block.Start, To: block.End, Kind: Return})
+		}
+		g.Edges = append(g.Edges, block.Succs...)
+	}
+
+	// routines are whatever is reachable from a call target without
+	// following calls into other routines
+	for entry, sites := range callers {
+		f := &Function{Entry: entry, Callers: sites}
+		if first := g.blocks[entry].Lines[0]; first.Words[0] == 109 {
+			f.Frame = first.Words[1]
+		}
+		seen := map[int64]bool{entry: true}
+		work := []int64{entry}
+		for len(work) != 0 {
+			block := g.blocks[work[len(work)-1]]
+			work = work[:len(work)-1]
+			f.Blocks = append(f.Blocks, block.Start)
+			if block.Return {
+				f.Returns = append(f.Returns, block.Start)
+			}
+			for _, edge := range block.Succs {
+				if edge.Kind != Call && !seen[edge.To] {
+					seen[edge.To] = true
+					work = append(work, edge.To)
+				}
+			}
+		}
+		sortAddresses(f.Blocks)
+		sortAddresses(f.Returns)
+		sortAddresses(f.Callers)
+		g.Functions = append(g.Functions, f)
+	}
+	sort.Slice(g.Functions, func(i, j int) bool { return g.Functions[i].Entry < g.Functions[j].Entry })
+
+	// position mode writes are the only ones whose address is known here.
+	// a write whose own address operand is patched, as programs do to index
+	// arrays, goes somewhere else at run time, so it is left out.  words that
+	// execution runs on into without them decoding are code too, as they are
+	// usually patched into an instruction before they are reached
+	entries := make(map[int64]bool)
+	for _, block := range g.Blocks {
+		if block.Undecodable {
+			entries[block.End] = true
+		}
+	}
+	var found []CodeWrite
+	var operands []int64
+	patched := make(map[int64]bool)
+	for _, block := range g.Blocks {
+		for _, line := range block.Lines {
+			inst := Decode(line.Words[0])
+			op, _ := LookupOp(inst.Opcode)
+			for i, role := range op.Params {
+				if role != Write || inst.Modes[i] != Position {
+					continue
+				}
+				target, ok := g.code[line.Words[i+1]]
+				if !ok && entries[line.Words[i+1]] {
+					target, ok = line.Words[i+1], true
+				}
+				if ok {
+					found = append(found, CodeWrite{PC: line.Address, Address: line.Words[i+1], Target: target})
+					operands = append(operands, line.Address+int64(i)+1)
+					patched[line.Words[i+1]] = true
+				}
+			}
+		}
+	}
+	for i, write := range found {
+		if !patched[operands[i]] {
+			g.CodeWrites = append(g.CodeWrites, write)
+		}
+	}
+	sort.Slice(g.CodeWrites, func(i, j int) bool { return g.CodeWrites[i].PC < g.CodeWrites[j].PC })
+	return g
+}
+
+func sortAddresses(addresses []int64) {
+	sort.Slice(addresses, func(i, j int) bool { return addresses[i] < addresses[j] })
+}
+
+// modified reports whether any instruction in the block is written to
+func (g *CFG) modified(block *Block) bool {
+	for _, write := range g.CodeWrites {
+		if write.Target >= block.Start && write.Target < block.End {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteDOT writes the graph in graphviz dot format.  routine entries have a
+// double border, modified blocks are shaded, and calls and returns are dashed
+// and dotted
+func (g *CFG) WriteDOT(w io.Writer) error {
+	ew := &errWriter{w: w}
+	ew.printf("digraph cfg {\n")
+	ew.printf("\tnode [shape=box fontname=monospace];\n")
+	for _, block := range g.Blocks {
+		var label strings.Builder
+		if f := g.Function(block.Start); f != nil {
+			fmt.Fprintf(&label, "func %d (frame %d)\\l", f.Entry, f.Frame)
+		}
+		for _, line := range block.Lines {
+			fmt.Fprintf(&label, "%d: %s\\l", line.Address, strings.Replace(line.String(), `"`, `\"`, -1))
+		}
+		attrs := ""
+		if g.Function(block.Start) != nil {
+			attrs += " peripheries=2"
+		}
+		if g.modified(block) {
+			attrs += " style=filled fillcolor=mistyrose"
+		}
+		ew.printf("\tb%d [label=\"%s\"%s];\n", block.Start, label.String(), attrs)
+	}
+	for _, edge := range g.Edges {
+		style := ""
+		switch edge.Kind {
+		case Jump:
+			style = " [color=blue]"
+		case Call:
+			style = " [style=dashed]"
+		case Return:
+			style = " [style=dotted]"
+		}
+		ew.printf("\tb%d -> b%d%s;\n", edge.From, edge.To, style)
+	}
+	ew.printf("}\n")
+	return ew.err
+}
+
+// jsonBlock is how a block appears in json, with its instructions as text
+type jsonBlock struct {
+	Start        int64    `json:"start"`
+	End          int64    `json:"end"`
+	Instructions []string `json:"instructions"`
+	Return       bool     `json:"return,omitempty"`
+	Indirect     bool     `json:"indirect,omitempty"`
+	Undecodable  bool     `json:"undecodable,omitempty"`
+}
+
+// WriteJSON writes the graph as a json object of blocks, edges, functions
+// and self-modifying writes
+func (g *CFG) WriteJSON(w io.Writer) error {
+	blocks := make([]jsonBlock, 0, len(g.Blocks))
+	for _, block := range g.Blocks {
+		jb := jsonBlock{
+			Start:       block.Start,
+			End:         block.End,
+			Return:      block.Return,
+			Indirect:    block.Indirect,
+			Undecodable: block.Undecodable,
+		}
+		for _, line := range block.Lines {
+			jb.Instructions = append(jb.Instructions, fmt.Sprintf("%d: %s", line.Address, line))
+		}
+		blocks = append(blocks, jb)
+	}
+	// empty lists are written as [] rather than null
+	edges := append([]Edge{}, g.Edges...)
+	functions := append([]*Function{}, g.Functions...)
+	writes := append([]CodeWrite{}, g.CodeWrites...)
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(struct {
+		Blocks     []jsonBlock `json:"blocks"`
+		Edges      []Edge      `json:"edges"`
+		Functions  []*Function `json:"functions"`
+		CodeWrites []CodeWrite `json:"code_writes"`
+	}{blocks, edges, functions, writes})
+}
@@ -0,0 +1,109 @@
+package intcode
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAnalyzeCalls(t *testing.T) {
+	g := Analyze(loadTestImage(t, "boost.txt"))
+
+	// the recursive routine boost uses to compute its answer
+	f := g.Function(922)
+	if f == nil {
+		t.Fatalf("no routine found at 922, found %v", g.Functions)
+	}
+	if f.Frame != 3 {
+		t.Errorf("frame %d, want 3", f.Frame)
+	}
+	if want := []int64{912, 939, 954}; !reflect.DeepEqual(f.Callers, want) {
+		t.Errorf("callers %v, want %v", f.Callers, want)
+	}
+	if want := []int64{968}; !reflect.DeepEqual(f.Returns, want) {
+		t.Errorf("returns %v, want %v", f.Returns, want)
+	}
+
+	// each call site continues at its return address once the call is done
+	var kinds []EdgeKind
+	for _, edge := range g.Block(931).Succs {
+		kinds = append(kinds, edge.Kind)
+	}
+	if want := []EdgeKind{Call, Return}; !reflect.DeepEqual(kinds, want) {
+		t.Errorf("call site edges %v, want %v", kinds, want)
+	}
+}
+
+func TestAnalyzeCodeWrites(t *testing.T) {
+	// the ADD patches the OUT's operand, and the IN writes past the code
+	g := Analyze(Parse("1101,0,10,7,3,11,4,0,99"))
+	want := []CodeWrite{{PC: 0, Address: 7, Target: 6}}
+	if !reflect.DeepEqual(g.CodeWrites, want) {
+		t.Errorf("code writes %+v, want %+v", g.CodeWrites, want)
+	}
+	if len(g.Blocks) != 1 || g.Blocks[0].End != 9 {
+		t.Errorf("got %d blocks, want the whole program as one", len(g.Blocks))
+	}
+}
+
+func TestAnalyzeUndecodableWrites(t *testing.T) {
+	// the start of day 5, which adds its input to the 1100 at 6 to make the
+	// instruction that runs there
+	g := Analyze(Parse("3,225,1,225,6,6,1100,1,238,225,104,0,99"))
+	if len(g.Blocks) != 1 || !g.Blocks[0].Undecodable || g.Blocks[0].End != 6 {
+		t.Fatalf("got blocks %+v, want one running into undecodable words at 6", g.Blocks)
+	}
+	want := []CodeWrite{{PC: 2, Address: 6, Target: 6}}
+	if !reflect.DeepEqual(g.CodeWrites, want) {
+		t.Errorf("code writes %+v, want %+v", g.CodeWrites, want)
+	}
+}
+
+func TestCFGExport(t *testing.T) {
+	g := Analyze(loadTestImage(t, "boost.txt"))
+
+	var dot bytes.Buffer
+	if err := g.WriteDOT(&dot); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(dot.String(), "b904 -> b922 [style=dashed]") {
+		t.Errorf("dot is missing the call from 912 to 922")
+	}
+
+	var out bytes.Buffer
+	if err := g.WriteJSON(&out); err != nil {
+		t.Fatal(err)
+	}
+	var decoded struct {
+		Blocks []struct {
+			Start int64 `json:"start"`
+		} `json:"blocks"`
+		Edges []struct {
+			Kind string `json:"kind"`
+		} `json:"edges"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.Blocks) != len(g.Blocks) || len(decoded.Edges) != len(g.Edges) {
+		t.Errorf("json has %d blocks and %d edges, want %d and %d", len(decoded.Blocks), len(decoded.Edges), len(g.Blocks), len(g.Edges))
+	}
+}
+
+func TestAnalyzeEmpty(t *testing.T) {
+	for _, program := range [][]int64{nil, {}} {
+		g := Analyze(program)
+		if len(g.Blocks) != 0 || len(g.Edges) != 0 {
+			t.Errorf("empty program gave %d blocks", len(g.Blocks))
+		}
+		var out bytes.Buffer
+		if err := g.WriteJSON(&out); err != nil {
+			t.Error(err)
+		}
+		if err := Decompile(&out, program); err != nil {
+			t.Error(err)
+		}
+	}
+}
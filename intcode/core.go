@@ -0,0 +1,254 @@
+package intcode
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// history keeps the most recent trace events in a ring
+type history struct {
+	events []TraceEvent
+	next   int
+	full   bool
+}
+
+func (h *history) add(event TraceEvent) {
+	h.events[h.next] = event
+	h.next++
+	if h.next == len(h.events) {
+		h.next, h.full = 0, true
+	}
+}
+
+// ordered returns the kept events, oldest first
+func (h *history) ordered() []TraceEvent {
+	if !h.full {
+		return append([]TraceEvent(nil), h.events[:h.next]...)
+	}
+	return append(append([]TraceEvent(nil), h.events[h.next:]...), h.events[:h.next]...)
+}
+
+// KeepHistory makes the computer remember the last n instructions it
+// executed, for inclusion in a core.  zero forgets them
+func (c *Computer) KeepHistory(n int) {
+	c.history = nil
+	if n > 0 {
+		c.history = &history{events: make([]TraceEvent, n)}
+	}
+	c.tracing = c.tracer != nil || c.history != nil
+}
+
+// History returns the instructions kept by KeepHistory, oldest first
+func (c *Computer) History() []TraceEvent {
+	if c.history == nil {
+		return nil
+	}
+	return c.history.ordered()
+}
+
+// SetCoreFile makes the computer write a core to path whenever the program
+// faults, however it is being run, keeping the last n instructions for it.
+// an empty path stops writing cores and keeping history
+func (c *Computer) SetCoreFile(path string, n int) {
+	c.corePath = path
+	if path == "" {
+		n = 0
+	}
+	c.KeepHistory(n)
+}
+
+// Segment is a run of memory starting at an address
+type Segment struct {
+	Start int64   `json:"start"`
+	Words []int64 `json:"words"`
+}
+
+// Core is a computer's state, saved for post-mortem inspection when its
+// program faults.  memory is in address order, with runs of zeros at the end
+// of memory left out
+type Core struct {
+	Fault        string       `json:"fault,omitempty"`
+	PC           int64        `json:"pc"`
+	RelativeBase int64        `json:"rb"`
+	Instruction  int64        `json:"inst"`
+	Executed     int64        `json:"executed"`
+	Input        []int64      `json:"input"`
+	Output       []int64      `json:"output"`
+	History      []TraceEvent `json:"history"`
+	Memory       []Segment    `json:"memory"`
+}
+
+// segments returns memory as runs of consecutive addresses, in order
+func (m *memory) segments() []Segment {
+	var segments []Segment
+	end := len(m.dense)
+	for end > 0 && m.dense[end-1] == 0 {
+		end--
+	}
+	if end > 0 {
+		segments = append(segments, Segment{Start: 0, Words: append([]int64(nil), m.dense[:end]...)})
+	}
+
+	var addresses []int64
+	for address := range m.sparse {
+		addresses = append(addresses, address)
+	}
+	sort.Slice(addresses, func(i, j int) bool { return addresses[i] < addresses[j] })
+	for _, address := range addresses {
+		last := len(segments) - 1
+		if last >= 0 && segments[last].Start+int64(len(segments[last].Words)) == address {
+			segments[last].Words = append(segments[last].Words, m.sparse[address])
+		} else {
+			segments = append(segments, Segment{Start: address, Words: []int64{m.sparse[address]}})
+		}
+	}
+	return segments
+}
+
+// Core saves the computer's state along with err, the fault that stopped it
+func (c *Computer) Core(err error) *Core {
+	core := &Core{
+		PC:           c.pc,
+		RelativeBase: c.relativeBase,
+		Instruction:  c.memory.get(c.pc),
+		Executed:     c.executed,
+		Input:        copyValues(c.inputs),
+		Output:       copyValues(c.outputs),
+		Memory:       c.memory.segments(),
+	}
+	if err != nil {
+		core.Fault = err.Error()
+	}
+	core.History = c.History()
+	return core
+}
+
+// dumpCore writes a core for the fault f to the computer's core file.  if
+// that fails, the failure is added to the fault
+func (c *Computer) dumpCore(f *Fault) {
+	err := func() error {
+		file, err := os.Create(c.corePath)
+		if err != nil {
+			return err
+		}
+		if err := WriteCore(file, c.Core(f)); err != nil {
+			file.Close()
+			return err
+		}
+		return file.Close()
+	}()
+	if err != nil {
+		f.Err = fmt.Errorf("%w (writing core: %v)", f.Err, err)
+	}
+}
+
+// WriteCore writes core to w as json
+func WriteCore(w io.Writer, core *Core) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(core)
+}
+
+// ReadCore reads a core written by WriteCore
+func ReadCore(r io.Reader) (*Core, error) {
+	core := &Core{}
+	if err := json.NewDecoder(r).Decode(core); err != nil {
+		return nil, fmt.Errorf("reading core: %w", err)
+	}
+	return core, nil
+}
+
+// LoadCore reads the core file at path
+func LoadCore(path string) (*Core, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadCore(f)
+}
+
+// Computer returns a computer in the state the core was saved in, keeping
+// the core's history
+func (core *Core) Computer() *Computer {
+	c := &Computer{
+		pc:           core.PC,
+		relativeBase: core.RelativeBase,
+		inputs:       copyValues(core.Input),
+		outputs:      copyValues(core.Output),
+		executed:     core.Executed,
+	}
+	for _, segment := range core.Memory {
+		for i, word := range segment.Words {
+			c.memory.set(segment.Start+int64(i), word)
+		}
+	}
+	if len(core.History) != 0 {
+		c.KeepHistory(len(core.History))
+		for _, event := range core.History {
+			c.history.add(event)
+		}
+	}
+	return c
+}
+
+// FormatEvent describes an executed instruction with the values it read and
+// wrote, as they were at the time
+func FormatEvent(event TraceEvent) string {
+	var operands []string
+	for _, access := range event.Reads {
+		operand := FormatOperand(access.Mode, access.Operand)
+		if access.Mode != Immediate {
+			operand += fmt.Sprintf("=%d", access.Value)
+		}
+		operands = append(operands, operand)
+	}
+	text := event.Mnemonic
+	if len(operands) != 0 {
+		text += " " + strings.Join(operands, ", ")
+	}
+	for _, access := range event.Writes {
+		text += fmt.Sprintf(" -> %s=%d", FormatOperand(access.Mode, access.Operand), access.Value)
+	}
+	return text
+}
+
+// WriteReport writes a readable summary of the core: the fault, registers,
+// pending io, recent instructions and a listing of memory
+func (core *Core) WriteReport(w io.Writer) error {
+	ew := &errWriter{w: w}
+	c := core.Computer()
+
+	if core.Fault != "" {
+		ew.printf("fault: %s\n", core.Fault)
+	}
+	ew.printf("pc %d  rb %d  executed %d\n", core.PC, core.RelativeBase, core.Executed)
+	ew.printf("pending input %v\n", core.Input)
+	ew.printf("pending output %v\n", core.Output)
+
+	if len(core.History) != 0 {
+		ew.printf("\nlast %d instructions\n", len(core.History))
+		for _, event := range core.History {
+			ew.printf("  %10d %6d  %s\n", event.Step, event.PC, FormatEvent(event))
+		}
+	}
+
+	for _, segment := range core.Memory {
+		end := segment.Start + int64(len(segment.Words))
+		ew.printf("\nmemory %d-%d\n", segment.Start, end-1)
+		for address := segment.Start; address < end; {
+			line := c.Disassemble(address)
+			marker := "  "
+			if address == core.PC {
+				marker = "=>"
+			}
+			ew.printf("%s %6d  %s\n", marker, address, line)
+			address += int64(len(line.Words))
+		}
+	}
+	return ew.err
+}
@@ -0,0 +1,95 @@
+package intcode
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCoreOnFault(t *testing.T) {
+	// counts down from 3, outputting each value, then runs into the 42
+	dir, err := ioutil.TempDir("", "core")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "core.json")
+	c := New("4,13,1001,13,-1,13,1005,13,0,42,0,0,0,3")
+	c.SetMemory(1<<40, 7)
+	c.QueueInput(9)
+	c.SetCoreFile(path, 4)
+
+	err = c.Run(context.Background(), nil, nil)
+	if !errors.Is(err, ErrUnknownOpcode) {
+		t.Fatalf("got %v, want an unknown opcode fault", err)
+	}
+	core, err := LoadCore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if core.PC != 9 || core.Instruction != 42 || core.Executed != 9 {
+		t.Errorf("core at pc %d instruction %d after %d, want 9, 42, 9", core.PC, core.Instruction, core.Executed)
+	}
+	if !reflect.DeepEqual(core.Input, []int64{9}) {
+		t.Errorf("pending input %v, want [9]", core.Input)
+	}
+
+	var steps []int64
+	for _, event := range core.History {
+		steps = append(steps, event.Step)
+	}
+	if want := []int64{6, 7, 8, 9}; !reflect.DeepEqual(steps, want) {
+		t.Errorf("history steps %v, want %v", steps, want)
+	}
+
+	var starts []int64
+	for _, segment := range core.Memory {
+		starts = append(starts, segment.Start)
+	}
+	if want := []int64{0, 1 << 40}; !reflect.DeepEqual(starts, want) {
+		t.Errorf("segments start at %v, want %v", starts, want)
+	}
+
+	restored := core.Computer()
+	if restored.PC() != 9 || restored.Memory(1<<40) != 7 || restored.Memory(13) != 0 {
+		t.Errorf("restored computer does not match the core")
+	}
+	if !reflect.DeepEqual(restored.History(), core.History) {
+		t.Errorf("restored computer lost the history")
+	}
+}
+
+func TestCoreOutsideRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "core")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// faults reached by stepping write a core too
+	stepped := filepath.Join(dir, "stepped.json")
+	c := New("1101,1,2,5,42")
+	c.SetCoreFile(stepped, 4)
+	if _, err := c.RunUntil(); !errors.Is(err, ErrUnknownOpcode) {
+		t.Fatalf("got %v, want an unknown opcode fault", err)
+	}
+	if core, err := LoadCore(stepped); err != nil || core.PC != 4 {
+		t.Errorf("stepping wrote core %+v, %v", core, err)
+	}
+
+	// closing a machine cancels it, which is not a fault worth a core
+	closed := filepath.Join(dir, "closed.json")
+	c = New("3,0,99")
+	c.SetCoreFile(closed, 4)
+	m := Start(context.Background(), c)
+	if err := m.Close(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("closed machine returned %v", err)
+	}
+	if _, err := os.Stat(closed); !os.IsNotExist(err) {
+		t.Errorf("closing the machine wrote a core")
+	}
+}
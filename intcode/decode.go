@@ -0,0 +1,47 @@
+package intcode
+
+// Mode is a parameter mode
+type Mode uint8
+
+const (
+	// Position parameters hold the address of their value
+	Position Mode = 0
+	// Immediate parameters hold their value
+	Immediate Mode = 1
+	// Relative parameters hold an address offset from the relative base
+	Relative Mode = 2
+)
+
+// Instruction is a decoded instruction word
+type Instruction struct {
+	Opcode int64
+	Modes  [3]Mode
+}
+
+// modeDivisors picks out the mode digit of each parameter
+var modeDivisors = [3]int64{100, 1000, 10000}
+
+// Decode splits an instruction word into its opcode and parameter modes.
+// modes are not validated here, as only the parameters an opcode uses matter
+func Decode(word int64) Instruction {
+	inst := Instruction{Opcode: word % 100}
+	for i, divisor := range modeDivisors {
+		inst.Modes[i] = Mode(word / divisor % 10)
+	}
+	return inst
+}
+
+// Encode builds the instruction word for opcode with the given parameter modes
+func Encode(opcode int64, modes []Mode) int64 {
+	word := opcode
+	for i, mode := range modes {
+		word += int64(mode) * modeDivisors[i]
+	}
+	return word
+}
+
+// cachedInstruction is a decoded instruction stored alongside dense memory
+type cachedInstruction struct {
+	Instruction
+	valid bool
+}
@@ -0,0 +1,931 @@
+package intcode
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// the decompiler lifts each routine found by Analyze, and the main program
+// reached from address 0, into pseudo-Go.  blocks are structured into if and
+// for statements using loops found from back edges and joins found from
+// post-dominators.  anything that does not fit is left as a goto.
+//
+// operands are named by where they live: mem[n] for position operands, and
+// for relative operands inside a routine with frame f, p1.. for the
+// arguments at [r-f+1].., l1.. for the locals after them and a1.. for the
+// argument slots of calls it makes at [r+1]..  other relative operands are
+// rb[n].  routines pass results back in their argument slots
+
+// noBlock stands for the lack of a block, as addresses are never negative
+const noBlock = -1
+
+// scratch names an operand
+type scratch struct {
+	mode    Mode
+	operand int64
+}
+
+// condition is a comparison that decides a jump
+type condition struct {
+	left, op, right string
+}
+
+var negations = map[string]string{"<": ">=", ">=": "<", "==": "!=", "!=": "=="}
+
+func (c condition) not() condition {
+	return condition{c.left, negations[c.op], c.right}
+}
+
+func (c condition) String() string {
+	return c.left + " " + c.op + " " + c.right
+}
+
+type decompiler struct {
+	program []int64
+	g       *CFG
+	// inputs and outputs name the instructions that do io
+	inputs, outputs map[int64]string
+	// flags are comparison results that are only ever read by the jump
+	// straight after, and so can be folded into its condition
+	flags map[scratch]bool
+	// patches lists the instructions that write into each instruction
+	patches map[int64][]int64
+	patched map[int64]bool
+	arity   map[int64]int64
+	// ending maps the address of the last instruction of each block to it
+	ending map[int64]*Block
+}
+
+// Decompile writes program as structured pseudo-Go, to show what it computes
+func Decompile(w io.Writer, program []int64) error {
+	g := Analyze(program)
+	d := &decompiler{
+		program: program,
+		g:       g,
+		inputs:  make(map[int64]string),
+		outputs: make(map[int64]string),
+		flags:   make(map[scratch]bool),
+		patches: make(map[int64][]int64),
+		patched: make(map[int64]bool),
+		arity:   make(map[int64]int64),
+		ending:  make(map[int64]*Block),
+	}
+	for _, block := range g.Blocks {
+		d.ending[block.Last().Address] = block
+	}
+	for _, write := range g.CodeWrites {
+		d.patches[write.Target] = append(d.patches[write.Target], write.PC)
+		d.patched[write.Address] = true
+	}
+	d.nameSites()
+	d.findFlags()
+	d.findArity()
+
+	ew := &errWriter{w: w}
+	ew.printf("// decompiled from %d words.  mem is memory and rb the relative base\n", len(program))
+	d.writeSites(ew, "input", d.inputs)
+	d.writeSites(ew, "output", d.outputs)
+
+	units := []*unit{d.newUnit("main", 0, nil)}
+	for _, f := range g.Functions {
+		units = append(units, d.newUnit(fmt.Sprintf("f%d", f.Entry), f.Entry, f))
+	}
+	for _, u := range units {
+		ew.printf("\n")
+		u.decompile()
+		u.write(ew)
+	}
+	return ew.err
+}
+
+// nameSites numbers the io instructions in address order
+func (d *decompiler) nameSites() {
+	for _, block := range d.g.Blocks {
+		for _, line := range block.Lines {
+			switch Decode(line.Words[0]).Opcode {
+			case 3:
+				d.inputs[line.Address] = fmt.Sprintf("in%d", len(d.inputs))
+			case 4:
+				d.outputs[line.Address] = fmt.Sprintf("out%d", len(d.outputs))
+			}
+		}
+	}
+}
+
+func (d *decompiler) writeSites(ew *errWriter, kind string, sites map[int64]string) {
+	if len(sites) == 0 {
+		return
+	}
+	var addresses []int64
+	for address := range sites {
+		addresses = append(addresses, address)
+	}
+	sortAddresses(addresses)
+	ew.printf("//\n// %s sites\n", kind)
+	for _, address := range addresses {
+		ew.printf("//\t%-6s pc %d\n", sites[address], address)
+	}
+}
+
+// foldable returns the comparison a block's final jump tests, if the
+// instruction before it is a LT or EQ into the jump's condition operand
+func foldable(block *Block) (Line, bool) {
+	n := len(block.Lines)
+	if n < 2 {
+		return Line{}, false
+	}
+	jump, compare := block.Lines[n-1], block.Lines[n-2]
+	ji, ci := Decode(jump.Words[0]), Decode(compare.Words[0])
+	if !isJump(ji.Opcode) || (ci.Opcode != 7 && ci.Opcode != 8) {
+		return Line{}, false
+	}
+	if ji.Modes[0] != ci.Modes[2] || jump.Words[1] != compare.Words[3] {
+		return Line{}, false
+	}
+	return compare, true
+}
+
+// findFlags finds the comparison results that nothing reads but the jump
+// that follows the comparison
+func (d *decompiler) findFlags() {
+	folded := make(map[int64]bool)
+	for _, block := range d.g.Blocks {
+		if compare, ok := foldable(block); ok {
+			inst := Decode(compare.Words[0])
+			d.flags[scratch{inst.Modes[2], compare.Words[3]}] = true
+			folded[block.Last().Address] = true
+		}
+	}
+	for _, block := range d.g.Blocks {
+		for _, line := range block.Lines {
+			if folded[line.Address] {
+				continue
+			}
+			inst := Decode(line.Words[0])
+			op, _ := LookupOp(inst.Opcode)
+			for i, role := range op.Params {
+				if role == Read {
+					delete(d.flags, scratch{inst.Modes[i], line.Words[i+1]})
+				}
+			}
+		}
+	}
+}
+
+// folded returns the comparison to fold into the block's final jump, if the
+// result is not needed afterwards.  that is when nothing else ever reads it,
+// or when every block the jump goes to overwrites it before reading it
+func (d *decompiler) folded(block *Block) (Line, bool) {
+	compare, ok := foldable(block)
+	if !ok {
+		return Line{}, false
+	}
+	flag := scratch{Decode(compare.Words[0]).Modes[2], compare.Words[3]}
+	if d.flags[flag] {
+		return compare, true
+	}
+	for _, edge := range block.Succs {
+		if !d.overwrites(d.g.Block(edge.To), flag) {
+			return Line{}, false
+		}
+	}
+	return compare, len(block.Succs) != 0
+}
+
+// overwrites reports whether block writes to operand before reading it
+func (d *decompiler) overwrites(block *Block, operand scratch) bool {
+	for _, line := range block.Lines {
+		inst := Decode(line.Words[0])
+		op, _ := LookupOp(inst.Opcode)
+		for i, role := range op.Params {
+			if role == Read && (scratch{inst.Modes[i], line.Words[i+1]}) == operand {
+				return false
+			}
+		}
+		for i, role := range op.Params {
+			if role == Write && (scratch{inst.Modes[i], line.Words[i+1]}) == operand {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// findArity works out how many arguments each routine takes, from the
+// argument slots its callers fill in before calling it
+func (d *decompiler) findArity() {
+	for _, f := range d.g.Functions {
+		arity := int64(0)
+		for _, site := range f.Callers {
+			block := d.ending[site]
+			for _, line := range block.Lines {
+				inst := Decode(line.Words[0])
+				op, _ := LookupOp(inst.Opcode)
+				for i, role := range op.Params {
+					if role == Write && inst.Modes[i] == Relative && line.Words[i+1] > arity {
+						arity = line.Words[i+1]
+					}
+				}
+			}
+		}
+		if f.Frame > 0 && arity > f.Frame-1 {
+			arity = f.Frame - 1
+		}
+		d.arity[f.Entry] = arity
+	}
+}
+
+// loop is a natural loop: a header and the blocks that can reach the header
+// through a back edge
+type loop struct {
+	header int64
+	body   map[int64]bool
+	// follow is where the loop exits to, or noBlock if it never does
+	follow int64
+}
+
+// outLine is a line of pseudo-Go, with the address it came from or noBlock
+type outLine struct {
+	depth int
+	text  string
+	pc    int64
+}
+
+// unit is the main program or a routine being decompiled
+type unit struct {
+	d     *decompiler
+	title string
+	entry int64
+	fn    *Function
+
+	blocks map[int64]bool
+	order  []int64
+	ipdom  map[int64]int64
+	loops  map[int64]*loop
+
+	out     []outLine
+	emitted map[int64]bool
+	starts  map[int64]int
+	labels  map[int64]bool
+	pending []int64
+}
+
+func (d *decompiler) newUnit(name string, entry int64, fn *Function) *unit {
+	u := &unit{
+		d:       d,
+		title:   name,
+		entry:   entry,
+		fn:      fn,
+		blocks:  make(map[int64]bool),
+		emitted: make(map[int64]bool),
+		starts:  make(map[int64]int),
+		labels:  make(map[int64]bool),
+	}
+	if d.g.Block(entry) == nil {
+		return u
+	}
+	work := []int64{entry}
+	u.blocks[entry] = true
+	for len(work) != 0 {
+		b := work[len(work)-1]
+		work = work[:len(work)-1]
+		u.order = append(u.order, b)
+		for _, s := range u.succs(b) {
+			if !u.blocks[s] {
+				u.blocks[s] = true
+				work = append(work, s)
+			}
+		}
+	}
+	sortAddresses(u.order)
+	u.findPostDominators()
+	u.findLoops()
+	return u
+}
+
+// succs returns the blocks control can pass to from b within the unit.
+// calls are stepped over to their return address
+func (u *unit) succs(b int64) []int64 {
+	var succs []int64
+	for _, edge := range u.d.g.Block(b).Succs {
+		if edge.Kind != Call {
+			succs = append(succs, edge.To)
+		}
+	}
+	return succs
+}
+
+// findPostDominators works out the immediate post-dominator of each block,
+// the join that every path from it passes through, with a virtual exit
+// after the blocks that leave the unit
+func (u *unit) findPostDominators() {
+	const exit = noBlock
+	all := map[int64]bool{exit: true}
+	for _, b := range u.order {
+		all[b] = true
+	}
+	copySet := func(set map[int64]bool) map[int64]bool {
+		c := make(map[int64]bool, len(set))
+		for k := range set {
+			c[k] = true
+		}
+		return c
+	}
+	pdom := make(map[int64]map[int64]bool)
+	for _, b := range u.order {
+		pdom[b] = copySet(all)
+	}
+	pdom[exit] = map[int64]bool{exit: true}
+
+	for changed := true; changed; {
+		changed = false
+		for i := len(u.order) - 1; i >= 0; i-- {
+			b := u.order[i]
+			succs := u.succs(b)
+			if len(succs) == 0 {
+				succs = []int64{exit}
+			}
+			next := copySet(pdom[succs[0]])
+			for _, s := range succs[1:] {
+				for k := range next {
+					if !pdom[s][k] {
+						delete(next, k)
+					}
+				}
+			}
+			next[b] = true
+			if len(next) != len(pdom[b]) {
+				pdom[b], changed = next, true
+			}
+		}
+	}
+
+	// blocks that never reach the exit keep every block as a post-dominator,
+	// and have no useful join
+	u.ipdom = make(map[int64]int64)
+	for _, b := range u.order {
+		u.ipdom[b] = noBlock
+		if !pdom[b][exit] || len(pdom[b]) == len(all) {
+			continue
+		}
+		best := -1
+		for p := range pdom[b] {
+			if p != b && p != exit && len(pdom[p]) > best {
+				u.ipdom[b], best = p, len(pdom[p])
+			}
+		}
+	}
+}
+
+// findLoops finds natural loops from the back edges of a depth first search
+func (u *unit) findLoops() {
+	u.loops = make(map[int64]*loop)
+	preds := make(map[int64][]int64)
+	for _, b := range u.order {
+		for _, s := range u.succs(b) {
+			preds[s] = append(preds[s], b)
+		}
+	}
+
+	onStack := make(map[int64]bool)
+	visited := make(map[int64]bool)
+	var visit func(b int64)
+	visit = func(b int64) {
+		visited[b], onStack[b] = true, true
+		for _, s := range u.succs(b) {
+			if onStack[s] {
+				u.addLoop(s, b, preds)
+			} else if !visited[s] {
+				visit(s)
+			}
+		}
+		onStack[b] = false
+	}
+	visit(u.entry)
+
+	for _, l := range u.loops {
+		var exits []int64
+		for b := range l.body {
+			for _, s := range u.succs(b) {
+				if !l.body[s] {
+					exits = append(exits, s)
+				}
+			}
+		}
+		sortAddresses(exits)
+		l.follow = noBlock
+		if len(exits) == 0 {
+			continue
+		}
+		l.follow = exits[0]
+		if join := u.ipdom[l.header]; join != noBlock && !l.body[join] {
+			l.follow = join
+		}
+		for _, s := range u.succs(l.header) {
+			if !l.body[s] {
+				l.follow = s
+			}
+		}
+	}
+}
+
+// addLoop adds the loop closed by the back edge from tail to header
+func (u *unit) addLoop(header, tail int64, preds map[int64][]int64) {
+	l := u.loops[header]
+	if l == nil {
+		l = &loop{header: header, body: map[int64]bool{header: true}}
+		u.loops[header] = l
+	}
+	work := []int64{tail}
+	for len(work) != 0 {
+		b := work[len(work)-1]
+		work = work[:len(work)-1]
+		if l.body[b] {
+			continue
+		}
+		l.body[b] = true
+		work = append(work, preds[b]...)
+	}
+}
+
+func (u *unit) line(depth int, pc int64, format string, args ...interface{}) {
+	u.out = append(u.out, outLine{depth: depth, text: fmt.Sprintf(format, args...), pc: pc})
+}
+
+// name renders an operand as a literal or a variable
+func (u *unit) name(mode Mode, operand int64) string {
+	switch mode {
+	case Immediate:
+		return fmt.Sprint(operand)
+	case Position:
+		return fmt.Sprintf("mem[%d]", operand)
+	}
+	if operand > 0 {
+		return fmt.Sprintf("a%d", operand)
+	}
+	if u.fn == nil || u.fn.Frame <= 0 {
+		return fmt.Sprintf("rb[%d]", operand)
+	}
+	slot := operand + u.fn.Frame
+	switch {
+	case slot <= 0 || operand == 0:
+		return fmt.Sprintf("rb[%d]", operand)
+	case slot <= u.d.arity[u.fn.Entry]:
+		return fmt.Sprintf("p%d", slot)
+	default:
+		return fmt.Sprintf("l%d", slot-u.d.arity[u.fn.Entry])
+	}
+}
+
+// operands names the parameters of line, split into reads and writes.  a
+// parameter the program patches at run time is named by where it is patched
+func (u *unit) operands(line Line) (reads, writes []string) {
+	inst := Decode(line.Words[0])
+	op, _ := LookupOp(inst.Opcode)
+	for i, role := range op.Params {
+		name := u.name(inst.Modes[i], line.Words[i+1])
+		if word := line.Address + int64(i) + 1; u.d.patched[word] {
+			switch inst.Modes[i] {
+			case Immediate:
+				name = fmt.Sprintf("mem[%d]", word)
+			case Position:
+				name = fmt.Sprintf("mem[mem[%d]]", word)
+			case Relative:
+				name = fmt.Sprintf("rb[mem[%d]]", word)
+			}
+		}
+		if role == Read {
+			reads = append(reads, name)
+		} else {
+			writes = append(writes, name)
+		}
+	}
+	return reads, writes
+}
+
+// statement renders a straight line instruction
+func (u *unit) statement(line Line) string {
+	inst := Decode(line.Words[0])
+	reads, writes := u.operands(line)
+	switch inst.Opcode {
+	case 1:
+		switch {
+		case reads[1] == "0":
+			return writes[0] + " = " + reads[0]
+		case reads[0] == "0":
+			return writes[0] + " = " + reads[1]
+		case strings.HasPrefix(reads[1], "-") && inst.Modes[1] == Immediate:
+			return writes[0] + " = " + reads[0] + " - " + reads[1][1:]
+		}
+		return writes[0] + " = " + reads[0] + " + " + reads[1]
+	case 2:
+		switch {
+		case reads[1] == "1":
+			return writes[0] + " = " + reads[0]
+		case reads[0] == "1":
+			return writes[0] + " = " + reads[1]
+		case reads[1] == "-1":
+			return writes[0] + " = -" + reads[0]
+		case reads[0] == "-1":
+			return writes[0] + " = -" + reads[1]
+		}
+		return writes[0] + " = " + reads[0] + " * " + reads[1]
+	case 3:
+		return writes[0] + " = " + u.d.inputs[line.Address] + "()"
+	case 4:
+		return u.d.outputs[line.Address] + "(" + reads[0] + ")"
+	case 7:
+		return writes[0] + " = b2i(" + reads[0] + " < " + reads[1] + ")"
+	case 8:
+		return writes[0] + " = b2i(" + reads[0] + " == " + reads[1] + ")"
+	case 9:
+		return "rb += " + reads[0]
+	case 99:
+		return "halt()"
+	}
+	op, _ := LookupOp(inst.Opcode)
+	call := strings.ToLower(op.Mnemonic) + "(" + strings.Join(reads, ", ") + ")"
+	if len(writes) == 0 {
+		return call
+	}
+	return strings.Join(writes, ", ") + " = " + call
+}
+
+// condition returns what makes the block's final jump taken, folding in a
+// comparison that only feeds it
+func (u *unit) condition(block *Block) condition {
+	jump := block.Last()
+	reads, _ := u.operands(jump)
+	taken := condition{reads[0], "!=", "0"}
+	if compare, ok := u.d.folded(block); ok {
+		args, _ := u.operands(compare)
+		taken = condition{args[0], "<", args[1]}
+		if Decode(compare.Words[0]).Opcode == 8 {
+			taken.op = "=="
+		}
+	}
+	if Decode(jump.Words[0]).Opcode == 6 {
+		return taken.not()
+	}
+	return taken
+}
+
+// shape describes how a block ends
+type shape struct {
+	// body is how many of the block's lines are plain statements
+	body     int
+	call     bool
+	cond     bool
+	taken    int64
+	fall     int64
+	next     int64
+	ret      bool
+	halt     bool
+	indirect bool
+	entryARB bool
+}
+
+func (u *unit) shape(block *Block) shape {
+	s := shape{body: len(block.Lines), taken: noBlock, fall: noBlock, next: noBlock}
+	for _, edge := range block.Succs {
+		switch edge.Kind {
+		case Fall:
+			s.fall = edge.To
+		case Jump, Call:
+			s.taken = edge.To
+		case Return:
+			s.next = edge.To
+		}
+	}
+	if u.fn != nil && block.Start == u.fn.Entry && block.Lines[0].Words[0] == 109 && block.Lines[0].Words[1] == u.fn.Frame {
+		s.entryARB = true
+	}
+
+	last := block.Last()
+	f := flowOf(u.d.program, last)
+	switch {
+	case Decode(last.Words[0]).Opcode == 99:
+		s.body--
+		s.halt = true
+		return s
+	case !isJump(Decode(last.Words[0]).Opcode):
+		s.next = s.fall
+		return s
+	}
+
+	// the jump itself, and whatever only sets it up, are not statements
+	s.body--
+	s.ret, s.indirect = f.ret, f.indirect && !f.call
+	switch {
+	case f.call:
+		s.call = true
+		s.body--
+	case f.next && (f.target || f.indirect):
+		s.cond = true
+		if _, ok := u.d.folded(block); ok {
+			s.body--
+		}
+	case f.target:
+		s.next = s.taken
+	}
+	if s.ret && u.fn != nil && s.body > 0 {
+		arb := block.Lines[s.body-1]
+		if arb.Words[0] == 109 && arb.Words[1] == -u.fn.Frame {
+			s.body--
+		}
+	}
+	return s
+}
+
+// statements emits the plain statements of a block
+func (u *unit) statements(block *Block, s shape, depth int) {
+	start := 0
+	if s.entryARB {
+		start = 1
+	}
+	for _, line := range block.Lines[start:s.body] {
+		text := u.statement(line)
+		if patchers, ok := u.d.patches[line.Address]; ok {
+			text += fmt.Sprintf(" /* patched by %s */", joinWords(patchers))
+		}
+		u.line(depth, line.Address, "%s", text)
+	}
+}
+
+// callText renders the call a block ends with
+func (u *unit) callText(block *Block) string {
+	jump := block.Last()
+	inst := Decode(jump.Words[0])
+	if inst.Modes[1] != Immediate {
+		reads, _ := u.operands(jump)
+		return "call(" + reads[1] + ")"
+	}
+	target := jump.Words[2]
+	var args []string
+	for i := int64(1); i <= u.d.arity[target]; i++ {
+		args = append(args, fmt.Sprintf("a%d", i))
+	}
+	return fmt.Sprintf("f%d(%s)", target, strings.Join(args, ", "))
+}
+
+// decompile structures the unit's blocks into lines of pseudo-Go
+func (u *unit) decompile() {
+	if len(u.order) == 0 {
+		u.line(0, noBlock, "// no code at %d", u.entry)
+		return
+	}
+	if u.fn == nil {
+		u.line(0, noBlock, "func main() {")
+	} else {
+		var params []string
+		for i := int64(1); i <= u.d.arity[u.fn.Entry]; i++ {
+			params = append(params, fmt.Sprintf("p%d", i))
+		}
+		signature := ""
+		if len(params) != 0 {
+			signature = strings.Join(params, ", ") + " int"
+		}
+		u.line(0, noBlock, "// %s has a frame of %d and is called from %s", u.title, u.fn.Frame, joinWords(u.fn.Callers))
+		u.line(0, noBlock, "func %s(%s) {", u.title, signature)
+	}
+	u.emit(u.entry, noBlock, nil, 1)
+	for len(u.pending) != 0 {
+		b := u.pending[0]
+		u.pending = u.pending[1:]
+		if !u.emitted[b] {
+			u.emit(b, noBlock, nil, 1)
+		}
+	}
+	u.line(0, noBlock, "}")
+}
+
+// emit emits the blocks from b until stop, inside the loop ctx if not nil
+func (u *unit) emit(b, stop int64, ctx *loop, depth int) {
+	for b != noBlock && b != stop {
+		if l := u.loops[b]; l != nil && !u.emitted[b] && (ctx == nil || ctx.header != b) {
+			u.emitLoop(l, depth)
+			b = l.follow
+			continue
+		}
+		if ctx != nil && b == ctx.header {
+			u.line(depth, noBlock, "continue")
+			return
+		}
+		if ctx != nil && b == ctx.follow {
+			u.line(depth, noBlock, "break")
+			return
+		}
+		if u.emitted[b] || ctx != nil && !ctx.body[b] {
+			u.gotoBlock(b, depth)
+			return
+		}
+		b = u.emitBlock(b, stop, ctx, depth)
+	}
+}
+
+func (u *unit) gotoBlock(b int64, depth int) {
+	u.labels[b] = true
+	if !u.emitted[b] {
+		u.pending = append(u.pending, b)
+	}
+	u.line(depth, noBlock, "goto L%d", b)
+}
+
+// exits reports whether going to b leaves the current structure, so that a
+// branch to it is written as a single break, continue or goto
+func (u *unit) exits(b, stop int64, ctx *loop) bool {
+	if b == stop {
+		return false
+	}
+	if ctx != nil && (b == ctx.header || b == ctx.follow || !ctx.body[b]) {
+		return true
+	}
+	return u.emitted[b]
+}
+
+// emitBlock emits one block, and any if statement it starts, and returns the
+// block to carry on from
+func (u *unit) emitBlock(b, stop int64, ctx *loop, depth int) int64 {
+	block := u.d.g.Block(b)
+	u.emitted[b] = true
+	u.starts[b] = len(u.out)
+	s := u.shape(block)
+	u.statements(block, s, depth)
+	last := block.Last().Address
+
+	switch {
+	case s.halt:
+		u.line(depth, last, "halt()")
+		return noBlock
+	case s.ret:
+		u.line(depth, last, "return")
+		return noBlock
+	case s.call:
+		u.line(depth, last, "%s", u.callText(block))
+		return s.next
+	case s.indirect && s.cond:
+		reads, _ := u.operands(block.Last())
+		u.line(depth, last, "if %s {", u.condition(block))
+		u.line(depth+1, last, "jump(%s)", reads[1])
+		u.line(depth, noBlock, "}")
+		return s.fall
+	case s.indirect:
+		reads, _ := u.operands(block.Last())
+		u.line(depth, last, "jump(%s)", reads[1])
+		return noBlock
+	case !s.cond && block.Undecodable:
+		u.runsOff(block, depth)
+		return noBlock
+	case !s.cond:
+		return s.next
+	}
+
+	cond := u.condition(block)
+	if block.Undecodable {
+		u.line(depth, last, "if %s {", cond)
+		u.emit(s.taken, noBlock, ctx, depth+1)
+		u.line(depth, noBlock, "}")
+		u.runsOff(block, depth)
+		return noBlock
+	}
+	join := u.ipdom[b]
+	if ctx != nil && join != noBlock && !ctx.body[join] {
+		join = noBlock
+	}
+	if join == noBlock && (s.taken == stop || s.fall == stop) {
+		join = stop
+	}
+
+	switch {
+	case u.exits(s.taken, stop, ctx):
+		u.line(depth, last, "if %s {", cond)
+		u.emit(s.taken, noBlock, ctx, depth+1)
+		u.line(depth, noBlock, "}")
+		return s.fall
+	case u.exits(s.fall, stop, ctx):
+		u.line(depth, last, "if %s {", cond.not())
+		u.emit(s.fall, noBlock, ctx, depth+1)
+		u.line(depth, noBlock, "}")
+		return s.taken
+	case join == s.fall:
+		u.line(depth, last, "if %s {", cond)
+		u.emit(s.taken, join, ctx, depth+1)
+		u.line(depth, noBlock, "}")
+	case join == s.taken:
+		u.line(depth, last, "if %s {", cond.not())
+		u.emit(s.fall, join, ctx, depth+1)
+		u.line(depth, noBlock, "}")
+	default:
+		start := len(u.out)
+		u.line(depth, last, "if %s {", cond)
+		u.emit(s.taken, join, ctx, depth+1)
+		middle := len(u.out)
+		u.line(depth, noBlock, "} else {")
+		u.emit(s.fall, join, ctx, depth+1)
+		switch {
+		case len(u.out) == middle+1:
+			// nothing to do otherwise
+			u.out = u.out[:middle]
+		case middle == start+1:
+			// nothing to do if the condition holds, so test the opposite
+			u.out[start].text = fmt.Sprintf("if %s {", cond.not())
+			u.out = append(u.out[:middle], u.out[middle+1:]...)
+			u.shiftStarts(middle)
+		}
+		u.line(depth, noBlock, "}")
+	}
+	return join
+}
+
+// runsOff notes that a block runs on into words that do not decode, which
+// the program usually patches into an instruction before it gets there
+func (u *unit) runsOff(block *Block, depth int) {
+	text := fmt.Sprintf("// runs into undecodable words at %d", block.End)
+	if patchers, ok := u.d.patches[block.End]; ok {
+		text += fmt.Sprintf(", patched at run time by %s", joinWords(patchers))
+	}
+	u.line(depth, noBlock, "%s", text)
+}
+
+// shiftStarts moves the recorded start of blocks after a line that has been
+// removed at index
+func (u *unit) shiftStarts(index int) {
+	for b, start := range u.starts {
+		if start > index {
+			u.starts[b] = start - 1
+		}
+	}
+}
+
+// emitLoop emits a loop, as a for with a condition when the header does
+// nothing but test whether to carry on
+func (u *unit) emitLoop(l *loop, depth int) {
+	block := u.d.g.Block(l.header)
+	s := u.shape(block)
+	if s.cond && !s.indirect && s.body == 0 && !s.entryARB && l.follow != noBlock {
+		var body int64 = noBlock
+		cond := u.condition(block)
+		switch {
+		case s.fall == l.follow && l.body[s.taken]:
+			body = s.taken
+		case s.taken == l.follow && l.body[s.fall]:
+			body, cond = s.fall, cond.not()
+		}
+		if body != noBlock {
+			u.emitted[l.header] = true
+			u.starts[l.header] = len(u.out)
+			u.line(depth, block.Last().Address, "for %s {", cond)
+			u.emit(body, l.header, l, depth+1)
+			u.line(depth, noBlock, "}")
+			return
+		}
+	}
+
+	u.line(depth, noBlock, "for {")
+	next := u.emitBlock(l.header, l.header, l, depth+1)
+	u.emit(next, l.header, l, depth+1)
+	u.line(depth, noBlock, "}")
+}
+
+// the column the address comments on statements start in
+const commentColumn = 56
+
+func indent(depth int) string {
+	if depth < 0 {
+		depth = 0
+	}
+	return strings.Repeat("\t", depth)
+}
+
+// write writes the unit's lines, with labels for the blocks jumped to and
+// the address of each statement as a comment
+func (u *unit) write(ew *errWriter) {
+	labelAt := make(map[int][]int64)
+	for b := range u.labels {
+		labelAt[u.starts[b]] = append(labelAt[u.starts[b]], b)
+	}
+	for i, line := range u.out {
+		labels := labelAt[i]
+		sort.Slice(labels, func(i, j int) bool { return labels[i] < labels[j] })
+		for _, b := range labels {
+			ew.printf("%sL%d:\n", indent(line.depth-1), b)
+		}
+		if line.pc == noBlock {
+			ew.printf("%s%s\n", indent(line.depth), line.text)
+			continue
+		}
+		// pad as if tabs were 8 wide, to line up the addresses
+		pad := commentColumn - 8*line.depth - len(line.text)
+		if pad < 1 {
+			pad = 1
+		}
+		ew.printf("%s%s%s// %d\n", indent(line.depth), line.text, strings.Repeat(" ", pad), line.pc)
+	}
+	if labels := labelAt[len(u.out)]; len(labels) != 0 {
+		for _, b := range labels {
+			ew.printf("L%d:\n", b)
+		}
+	}
+}
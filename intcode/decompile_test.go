@@ -0,0 +1,83 @@
+package intcode
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// decompiled returns the pseudo-Go for program without the address comments
+func decompiled(t *testing.T, program []int64) string {
+	t.Helper()
+	var out bytes.Buffer
+	if err := Decompile(&out, program); err != nil {
+		t.Fatal(err)
+	}
+	return regexp.MustCompile(` +// \d+\n`).ReplaceAllString(out.String(), "\n")
+}
+
+func TestDecompileWhile(t *testing.T) {
+	// counts down from its input, outputting each value
+	got := decompiled(t, Parse("3,20,1006,20,14,4,20,1001,20,-1,20,1105,1,2,99"))
+	want := `func main() {
+	mem[20] = in0()
+	for mem[20] != 0 {
+		out0(mem[20])
+		mem[20] = mem[20] - 1
+	}
+	halt()
+}
+`
+	if !strings.HasSuffix(got, want) {
+		t.Errorf("got\n%s\nwant it to end with\n%s", got, want)
+	}
+}
+
+func TestDecompileFrames(t *testing.T) {
+	got := decompiled(t, loadTestImage(t, "boost.txt"))
+	want := `func f922(p1 int) {
+	mem[63] = b2i(p1 < 3)
+	if mem[63] != 0 {
+		p1 = p1
+	} else {
+		a1 = p1 - 1
+		f922(a1)
+		l1 = a1
+		a1 = p1 - 3
+		f922(a1)
+		p1 = a1 + l1
+	}
+	return
+}
+`
+	if !strings.Contains(got, want) {
+		t.Errorf("got\n%s\nwant it to contain\n%s", got, want)
+	}
+}
+
+func TestDecompileUndecodable(t *testing.T) {
+	// the start of day 5, which patches the instruction it runs on into
+	got := decompiled(t, Parse("3,225,1,225,6,6,1100,1,238,225,104,0,99"))
+	want := `func main() {
+	mem[225] = in0()
+	mem[6] = mem[225] + mem[6]
+	// runs into undecodable words at 6, patched at run time by 2
+}
+`
+	if !strings.HasSuffix(got, want) {
+		t.Errorf("got\n%s\nwant it to end with\n%s", got, want)
+	}
+
+	// a conditional jump can fall into them too
+	got = decompiled(t, Parse("3,20,1005,20,8,0,0,0,99"))
+	want = `	if mem[20] != 0 {
+		halt()
+	}
+	// runs into undecodable words at 5
+}
+`
+	if !strings.HasSuffix(got, want) {
+		t.Errorf("got\n%s\nwant it to end with\n%s", got, want)
+	}
+}
@@ -0,0 +1,155 @@
+package intcode
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// at most this many data words are grouped onto a single line
+const dataWordsPerLine = 8
+
+// Line is one line of a disassembly: either a single instruction, or a run
+// of words that could not be decoded as instructions
+type Line struct {
+	Address int64
+	Words   []int64
+	Data    bool
+}
+
+// String renders the line as a mnemonic and operands, eg
+// "ADD [r+3], #5 -> [224]", or as "DATA" and the raw words
+func (l Line) String() string {
+	if l.Data {
+		return "DATA " + joinWords(l.Words)
+	}
+
+	inst := Decode(l.Words[0])
+	op, _ := LookupOp(inst.Opcode)
+
+	var reads, writes []string
+	for i, role := range op.Params {
+		operand := FormatOperand(inst.Modes[i], l.Words[i+1])
+		if role == Read {
+			reads = append(reads, operand)
+		} else {
+			writes = append(writes, operand)
+		}
+	}
+
+	text := op.Mnemonic
+	if len(reads) != 0 {
+		text += " " + strings.Join(reads, ", ")
+	}
+	if len(writes) != 0 {
+		text += " -> " + strings.Join(writes, ", ")
+	}
+	return text
+}
+
+// FormatOperand renders a parameter with its mode marker: #5 for immediate,
+// [224] for position and [r+3] for relative
+func FormatOperand(mode Mode, value int64) string {
+	switch mode {
+	case Immediate:
+		return fmt.Sprintf("#%d", value)
+	case Relative:
+		return fmt.Sprintf("[r%+d]", value)
+	default:
+		return fmt.Sprintf("[%d]", value)
+	}
+}
+
+func joinWords(words []int64) string {
+	asStrings := make([]string, len(words))
+	for i, word := range words {
+		asStrings[i] = fmt.Sprint(word)
+	}
+	return strings.Join(asStrings, ", ")
+}
+
+// decodeAt returns the instruction line at address, or false if the words
+// there are not a valid instruction
+func decodeAt(fetch func(int64) int64, address int64, end int64) (Line, bool) {
+	word := fetch(address)
+	inst := Decode(word)
+	op, ok := LookupOp(inst.Opcode)
+	if !ok || address+op.Size() > end {
+		return Line{}, false
+	}
+	for i, role := range op.Params {
+		mode := inst.Modes[i]
+		if mode > Relative || (role == Write && mode == Immediate) {
+			return Line{}, false
+		}
+	}
+	// stray mode digits on parameters the opcode does not have would be lost
+	// when reassembled, so treat such words as data
+	if word != Encode(op.Opcode, inst.Modes[:len(op.Params)]) {
+		return Line{}, false
+	}
+	line := Line{Address: address, Words: make([]int64, op.Size())}
+	for i := range line.Words {
+		line.Words[i] = fetch(address + int64(i))
+	}
+	return line, true
+}
+
+// Disassemble decodes program from start to end.  words that do not decode
+// as a valid instruction are grouped into data lines
+func Disassemble(program []int64) []Line {
+	fetch := func(address int64) int64 { return program[address] }
+	end := int64(len(program))
+
+	var lines []Line
+	for address := int64(0); address < end; {
+		if line, ok := decodeAt(fetch, address, end); ok {
+			lines = append(lines, line)
+			address += int64(len(line.Words))
+			continue
+		}
+		if n := len(lines); n != 0 && lines[n-1].Data && len(lines[n-1].Words) < dataWordsPerLine {
+			lines[n-1].Words = append(lines[n-1].Words, program[address])
+		} else {
+			lines = append(lines, Line{Address: address, Words: []int64{program[address]}, Data: true})
+		}
+		address++
+	}
+	return lines
+}
+
+// Disassemble decodes the single instruction at address in the computer's
+// memory.  if it is not a valid instruction, a one word data line is returned
+func (c *Computer) Disassemble(address int64) Line {
+	end := address + int64(len(modeDivisors)) + 1
+	if line, ok := decodeAt(c.memory.get, address, end); ok {
+		return line
+	}
+	return Line{Address: address, Words: []int64{c.memory.get(address)}, Data: true}
+}
+
+// WriteListing writes an annotated listing of program to w, one line per
+// instruction, showing the address, the raw words and the disassembly
+func WriteListing(w io.Writer, program []int64) error {
+	for _, line := range Disassemble(program) {
+		raw := joinWords(line.Words)
+		if line.Data {
+			raw = fmt.Sprintf("(%d words)", len(line.Words))
+		}
+		if _, err := fmt.Fprintf(w, "%6d  %-32s  %s\n", line.Address, raw, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteSource writes program to w as assembler source that Assemble turns
+// back into the same words.  each line is commented with its address
+func WriteSource(w io.Writer, program []int64) error {
+	for _, line := range Disassemble(program) {
+		if _, err := fmt.Fprintf(w, "\t%-40s ; %d\n", line, line.Address); err != nil {
+			return err
+		}
+	}
+	return nil
+}
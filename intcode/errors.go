@@ -0,0 +1,59 @@
+package intcode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrUnknownOpcode is returned when the instruction at pc is not a known opcode
+	ErrUnknownOpcode = errors.New("unknown opcode")
+	// ErrBadMode is returned when a parameter mode is not valid for its parameter
+	ErrBadMode = errors.New("bad parameter mode")
+	// ErrNegativeAddress is returned when the program accesses memory below address 0
+	ErrNegativeAddress = errors.New("negative address")
+	// ErrInputClosed is returned when the program needs a value and the input has none left
+	ErrInputClosed = errors.New("input closed")
+	// ErrInstructionLimit is returned when the program runs past its instruction budget
+	ErrInstructionLimit = errors.New("instruction limit exceeded")
+	// ErrMemoryLimit is returned when the program accesses memory above its address ceiling
+	ErrMemoryLimit = errors.New("memory limit exceeded")
+	// ErrOverflow is returned when checked arithmetic overflows, or a big
+	// value used as an address does not fit in an int64
+	ErrOverflow = errors.New("integer overflow")
+)
+
+// Fault is the error returned when a program fails.  it records the machine
+// state at the failing instruction.  use errors.Is to test for the cause,
+// which for a cancelled Run is the context's error
+type Fault struct {
+	Err          error
+	PC           int64
+	Instruction  int64
+	RelativeBase int64
+}
+
+func (f *Fault) Error() string {
+	return fmt.Sprintf("%v: pc(%d) instruction(%d) relative base(%d)", f.Err, f.PC, f.Instruction, f.RelativeBase)
+}
+
+// Unwrap returns the cause of the fault
+func (f *Fault) Unwrap() error {
+	return f.Err
+}
+
+func (c *Computer) fault(err error) *Fault {
+	return c.faultAt(c.pc, err)
+}
+
+// faultAt builds the fault for err at pc, and writes a core for it if the
+// computer has a core file.  being cancelled is not a fault of the program,
+// so no core is written for it
+func (c *Computer) faultAt(pc int64, err error) *Fault {
+	f := &Fault{Err: err, PC: pc, Instruction: c.memory.get(pc), RelativeBase: c.relativeBase}
+	if c.corePath != "" && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
+		c.dumpCore(f)
+	}
+	return f
+}
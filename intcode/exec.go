@@ -0,0 +1,88 @@
+package intcode
+
+// Jump moves the pc to address once the current instruction completes,
+// instead of past it.  it is for use by handlers
+func (c *Computer) Jump(address int64) {
+	c.jumped = true
+	c.target = address
+}
+
+func boolWord(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func execAdd(c *Computer, args []int64) (Status, error) {
+	sum := args[0] + args[1]
+	if c.checked && addOverflows(args[0], args[1], sum) {
+		return Error, c.fault(ErrOverflow)
+	}
+	args[2] = sum
+	return Running, nil
+}
+
+func execMul(c *Computer, args []int64) (Status, error) {
+	product := args[0] * args[1]
+	if c.checked && mulOverflows(args[0], args[1], product) {
+		return Error, c.fault(ErrOverflow)
+	}
+	args[2] = product
+	return Running, nil
+}
+
+func execIn(c *Computer, args []int64) (Status, error) {
+	if len(c.inputs) == 0 {
+		return NeedsInput, nil
+	}
+	value := c.inputs[0]
+	c.inputs = c.inputs[1:]
+	if c.tracing {
+		c.event.Input = &value
+	}
+	args[0] = value
+	return Running, nil
+}
+
+func execOut(c *Computer, args []int64) (Status, error) {
+	value := args[0]
+	c.outputs = append(c.outputs, value)
+	if c.tracing {
+		c.event.Output = &value
+	}
+	return ProducedOutput, nil
+}
+
+func execJNZ(c *Computer, args []int64) (Status, error) {
+	if args[0] != 0 {
+		c.Jump(args[1])
+	}
+	return Running, nil
+}
+
+func execJZ(c *Computer, args []int64) (Status, error) {
+	if args[0] == 0 {
+		c.Jump(args[1])
+	}
+	return Running, nil
+}
+
+func execLT(c *Computer, args []int64) (Status, error) {
+	args[2] = boolWord(args[0] < args[1])
+	return Running, nil
+}
+
+func execEQ(c *Computer, args []int64) (Status, error) {
+	args[2] = boolWord(args[0] == args[1])
+	return Running, nil
+}
+
+func execARB(c *Computer, args []int64) (Status, error) {
+	c.relativeBase += args[0]
+	return Running, nil
+}
+
+func execHLT(c *Computer, args []int64) (Status, error) {
+	return Halted, nil
+}
@@ -0,0 +1,48 @@
+package intcode
+
+import (
+	"fmt"
+	"io"
+)
+
+// ExitError is the cause of the fault returned when a program exits with a
+// non zero code through a HaltWithCode instruction
+type ExitError struct {
+	Code int64
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit code %d", e.Code)
+}
+
+// DebugPrint returns an extension op, DBG, that writes its one parameter and
+// the pc to w.  it does not produce output.  install it with Register
+func DebugPrint(opcode int64, w io.Writer) Op {
+	return Op{
+		Opcode:   opcode,
+		Mnemonic: "DBG",
+		Params:   []Role{Read},
+		Exec: func(c *Computer, args []int64) (Status, error) {
+			fmt.Fprintf(w, "debug pc(%d): %d\n", c.PC(), args[0])
+			return Running, nil
+		},
+	}
+}
+
+// HaltWithCode returns an extension op, EXIT, that halts the program with its
+// one parameter as an exit code.  a zero code halts normally, anything else
+// stops the program with a fault wrapping an *ExitError.  install it with
+// Register
+func HaltWithCode(opcode int64) Op {
+	return Op{
+		Opcode:   opcode,
+		Mnemonic: "EXIT",
+		Params:   []Role{Read},
+		Exec: func(c *Computer, args []int64) (Status, error) {
+			if args[0] == 0 {
+				return Halted, nil
+			}
+			return Error, c.fault(&ExitError{Code: args[0]})
+		},
+	}
+}
@@ -0,0 +1,103 @@
+package intcode
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// an image starts with a fixed size header:
+//
+//	magic    4 bytes  "ICIM"
+//	version  2 bytes  big endian
+//	words    8 bytes  big endian word count
+//	crc      4 bytes  big endian crc32 (ieee) of the encoded words
+//
+// followed by each word as a signed varint
+const (
+	imageMagic      = "ICIM"
+	imageVersion    = 1
+	imageHeaderSize = 18
+)
+
+var (
+	// ErrBadImage is returned when data is not a well formed program image
+	ErrBadImage = errors.New("bad program image")
+	// ErrImageVersion is returned for images written by a newer format version
+	ErrImageVersion = errors.New("unsupported program image version")
+	// ErrChecksum is returned when an image's words do not match its checksum
+	ErrChecksum = errors.New("program image checksum mismatch")
+)
+
+// WriteImage writes program to w in the binary image format
+func WriteImage(w io.Writer, program []int64) error {
+	var words bytes.Buffer
+	buf := make([]byte, binary.MaxVarintLen64)
+	for _, word := range program {
+		words.Write(buf[:binary.PutVarint(buf, word)])
+	}
+
+	header := make([]byte, imageHeaderSize)
+	copy(header, imageMagic)
+	binary.BigEndian.PutUint16(header[4:], imageVersion)
+	binary.BigEndian.PutUint64(header[6:], uint64(len(program)))
+	binary.BigEndian.PutUint32(header[14:], crc32.ChecksumIEEE(words.Bytes()))
+
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	_, err := w.Write(words.Bytes())
+	return err
+}
+
+// isImage reports whether data starts like a program image
+func isImage(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(imageMagic))
+}
+
+// ReadImage reads a program written by WriteImage, checking its checksum
+func ReadImage(r io.Reader) ([]int64, error) {
+	header := make([]byte, imageHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("%w: header: %v", ErrBadImage, err)
+	}
+	if !isImage(header) {
+		return nil, fmt.Errorf("%w: no magic number", ErrBadImage)
+	}
+	if version := binary.BigEndian.Uint16(header[4:]); version != imageVersion {
+		return nil, fmt.Errorf("%w %d", ErrImageVersion, version)
+	}
+	count := binary.BigEndian.Uint64(header[6:])
+	crc := binary.BigEndian.Uint32(header[14:])
+
+	// the count is not trusted for allocation until the words are read
+	hash := crc32.NewIEEE()
+	words := bufio.NewReader(io.TeeReader(r, hash))
+	var program []int64
+	for i := uint64(0); i < count; i++ {
+		word, err := binary.ReadVarint(words)
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: word %d of %d: %v", ErrBadImage, i, count, err)
+		}
+		program = append(program, word)
+	}
+	if _, err := words.ReadByte(); err == nil {
+		return nil, fmt.Errorf("%w: data after the last word", ErrBadImage)
+	} else if err != io.EOF {
+		return nil, err
+	}
+	if hash.Sum32() != crc {
+		return nil, ErrChecksum
+	}
+	if len(program) == 0 {
+		return nil, ErrEmptyProgram
+	}
+	return program, nil
+}
@@ -0,0 +1,52 @@
+package intcode
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestImageRoundTrip(t *testing.T) {
+	for _, program := range [][]int64{
+		loadTestImage(t, "boost.txt"),
+		{math.MinInt64, -1, 0, 1, math.MaxInt64},
+	} {
+		var image bytes.Buffer
+		if err := WriteImage(&image, program); err != nil {
+			t.Fatal(err)
+		}
+		got, err := ReadProgram(bytes.NewReader(image.Bytes()))
+		if err != nil || !reflect.DeepEqual(got, program) {
+			t.Errorf("round trip gave %v, %v", got, err)
+		}
+	}
+}
+
+func TestImageCorruption(t *testing.T) {
+	var image bytes.Buffer
+	WriteImage(&image, []int64{1, 2, 3, 99})
+	valid := image.Bytes()
+
+	corrupt := func(change func(data []byte) []byte) error {
+		data := change(append([]byte(nil), valid...))
+		_, err := ReadImage(bytes.NewReader(data))
+		return err
+	}
+	for _, test := range []struct {
+		name   string
+		change func(data []byte) []byte
+		want   error
+	}{
+		{"flipped word", func(d []byte) []byte { d[imageHeaderSize] ^= 1; return d }, ErrChecksum},
+		{"truncated", func(d []byte) []byte { return d[:len(d)-1] }, ErrBadImage},
+		{"trailing data", func(d []byte) []byte { return append(d, 0) }, ErrBadImage},
+		{"short header", func(d []byte) []byte { return d[:10] }, ErrBadImage},
+		{"future version", func(d []byte) []byte { d[5] = 2; return d }, ErrImageVersion},
+	} {
+		if err := corrupt(test.change); !errors.Is(err, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, err, test.want)
+		}
+	}
+}
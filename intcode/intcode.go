@@ -0,0 +1,386 @@
+package intcode
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Computer is an intcode machine supporting opcodes 1-9 and 99 with
+// position, immediate and relative parameter modes
+type Computer struct {
+	pc           int64
+	relativeBase int64
+	memory       memory
+	inputs       []int64
+	outputs      []int64
+	executed     int64
+	halted       bool
+	limits       Limits
+	checked      bool
+	args         [maxParams]int64
+	jumped       bool
+	target       int64
+	tracer       Tracer
+	history      *history
+	corePath     string
+	tracing      bool
+	event        TraceEvent
+}
+
+// Format joins program into the comma separated form read by Parse
+func Format(program []int64) string {
+	asStrings := make([]string, len(program))
+	for index, value := range program {
+		asStrings[index] = strconv.FormatInt(value, 10)
+	}
+	return strings.Join(asStrings, ",")
+}
+
+// New returns a computer loaded with the comma separated program.  like
+// Parse, it panics if the program is malformed
+func New(instructions string) *Computer {
+	return NewFromProgram(Parse(instructions))
+}
+
+// NewFromProgram returns a computer loaded with a copy of program
+func NewFromProgram(program []int64) *Computer {
+	return &Computer{memory: newMemory(program)}
+}
+
+// PC returns the address of the next instruction
+func (c *Computer) PC() int64 {
+	return c.pc
+}
+
+// RelativeBase returns the base address of relative mode parameters
+func (c *Computer) RelativeBase() int64 {
+	return c.relativeBase
+}
+
+// Executed returns the number of instructions executed so far
+func (c *Computer) Executed() int64 {
+	return c.executed
+}
+
+// PendingInput returns the queued input values not yet consumed
+func (c *Computer) PendingInput() []int64 {
+	return append([]int64(nil), c.inputs...)
+}
+
+// Memory returns the value stored at address
+func (c *Computer) Memory(address int64) int64 {
+	return c.memory.get(address)
+}
+
+// SetMemory stores value at address
+func (c *Computer) SetMemory(address int64, value int64) {
+	c.memory.set(address, value)
+}
+
+// address returns the memory address referenced by the parameter at pcOffset
+func (c *Computer) address(inst Instruction, pcOffset int64) (int64, error) {
+	var address int64
+	switch inst.Modes[pcOffset-1] {
+	case Position:
+		address = c.memory.get(c.pc + pcOffset)
+	case Relative:
+		address = c.memory.get(c.pc+pcOffset) + c.relativeBase
+	default:
+		return 0, c.fault(ErrBadMode)
+	}
+	if address < 0 {
+		return 0, c.fault(ErrNegativeAddress)
+	}
+	if c.outOfRange(address) {
+		return 0, c.fault(ErrMemoryLimit)
+	}
+	return address, nil
+}
+
+func (c *Computer) read(inst Instruction, pcOffset int64) (int64, error) {
+	if inst.Modes[pcOffset-1] == Immediate {
+		value := c.memory.get(c.pc + pcOffset)
+		if c.tracing {
+			c.traceAccess(inst, pcOffset, c.pc+pcOffset, value, Read)
+		}
+		return value, nil
+	}
+	address, err := c.address(inst, pcOffset)
+	if err != nil {
+		return 0, err
+	}
+	value := c.memory.get(address)
+	if c.tracing {
+		c.traceAccess(inst, pcOffset, address, value, Read)
+	}
+	return value, nil
+}
+
+func (c *Computer) write(inst Instruction, pcOffset int64, value int64) error {
+	address, err := c.address(inst, pcOffset)
+	if err != nil {
+		return err
+	}
+	if c.tracing {
+		c.traceAccess(inst, pcOffset, address, value, Write)
+	}
+	c.memory.set(address, value)
+	return nil
+}
+
+// Status reports why Step or RunUntil returned
+type Status int
+
+const (
+	// Running means the instruction executed and the program can continue
+	Running Status = iota
+	// Halted means the program executed opcode 99
+	Halted
+	// NeedsInput means the program is at an input instruction and no input is queued
+	NeedsInput
+	// ProducedOutput means the program queued an output value
+	ProducedOutput
+	// Error means the program faulted
+	Error
+)
+
+func (s Status) String() string {
+	switch s {
+	case Running:
+		return "running"
+	case Halted:
+		return "halted"
+	case NeedsInput:
+		return "needs input"
+	case ProducedOutput:
+		return "produced output"
+	case Error:
+		return "error"
+	}
+	return fmt.Sprintf("Status(%d)", int(s))
+}
+
+// QueueInput queues values to be consumed by the program's input instructions
+func (c *Computer) QueueInput(values ...int64) {
+	c.inputs = append(c.inputs, values...)
+}
+
+// TakeOutput removes and returns the oldest queued output value
+func (c *Computer) TakeOutput() (int64, bool) {
+	if len(c.outputs) == 0 {
+		return 0, false
+	}
+	value := c.outputs[0]
+	c.outputs = c.outputs[1:]
+	return value, true
+}
+
+// TakeOutputs removes and returns all queued output values
+func (c *Computer) TakeOutputs() []int64 {
+	outputs := c.outputs
+	c.outputs = nil
+	return outputs
+}
+
+// Step executes a single instruction.  input instructions consume values
+// queued with QueueInput; when none are queued the instruction is not executed
+// and NeedsInput is returned.  output instructions queue their value for
+// TakeOutput and return ProducedOutput.  a halted program stays halted, and
+// its HLT is only executed, counted and traced once
+func (c *Computer) Step() (Status, error) {
+	if c.halted {
+		return Halted, nil
+	}
+	if c.tracing {
+		c.event = TraceEvent{
+			PC:           c.pc,
+			RelativeBase: c.relativeBase,
+			Instruction:  c.memory.get(c.pc),
+		}
+	}
+
+	status, err := c.step()
+	if status == NeedsInput || status == Error {
+		return status, err
+	}
+	if status == Halted {
+		c.halted = true
+	}
+
+	c.executed++
+	if c.tracing {
+		c.event.Step = c.executed
+		if c.tracer != nil {
+			c.tracer.Trace(c.event)
+		}
+		if c.history != nil {
+			c.history.add(c.event)
+		}
+	}
+	return status, err
+}
+
+func (c *Computer) step() (Status, error) {
+
+	if c.pc < 0 {
+		return Error, c.fault(ErrNegativeAddress)
+	}
+	if c.outOfRange(c.pc) {
+		return Error, c.fault(ErrMemoryLimit)
+	}
+	if c.limits.MaxInstructions > 0 && c.executed >= c.limits.MaxInstructions {
+		return Error, c.fault(ErrInstructionLimit)
+	}
+
+	inst := c.memory.instruction(c.pc)
+	op, ok := LookupOp(inst.Opcode)
+	if !ok {
+		return Error, c.fault(ErrUnknownOpcode)
+	}
+	if c.tracing {
+		c.event.Mnemonic = op.Mnemonic
+	}
+
+	args := c.args[:len(op.Params)]
+	for i := range args {
+		args[i] = 0
+	}
+	for i := int64(0); i < op.reads; i++ {
+		value, err := c.read(inst, i+1)
+		if err != nil {
+			return Error, err
+		}
+		args[i] = value
+	}
+
+	c.jumped = false
+	status, err := op.Exec(c, args)
+	if status == Error {
+		if _, ok := err.(*Fault); !ok && err != nil {
+			err = c.fault(err)
+		}
+		return status, err
+	}
+	if status == NeedsInput {
+		return status, err
+	}
+
+	for i := op.reads; i < int64(len(args)); i++ {
+		if err := c.write(inst, i+1, args[i]); err != nil {
+			return Error, err
+		}
+	}
+	switch {
+	case c.jumped:
+		c.pc = c.target
+	case status != Halted:
+		c.pc += op.Size()
+	}
+	return status, err
+}
+
+// RunUntil steps the program until it returns one of the stop statuses.
+// Halted, NeedsInput and Error always stop the program, as it cannot make
+// progress past them.  with no stop statuses, every status other than
+// Running stops the program
+func (c *Computer) RunUntil(stop ...Status) (Status, error) {
+	for {
+		status, err := c.Step()
+		switch status {
+		case Running:
+			continue
+		case Halted, NeedsInput, Error:
+			return status, err
+		}
+		if len(stop) == 0 {
+			return status, err
+		}
+		for _, s := range stop {
+			if s == status {
+				return status, err
+			}
+		}
+	}
+}
+
+// how many instructions Run executes between checks for cancellation
+const cancelCheckInterval = 1024
+
+// stepper is a machine that can be run one instruction at a time
+type stepper interface {
+	Step() (Status, error)
+	PC() int64
+	fault(err error) *Fault
+	faultAt(pc int64, err error) *Fault
+}
+
+// drive steps m until its program halts, faults or ctx is done.  when the
+// program needs input, read must queue a value or return io.EOF once there
+// are none, and when it produces output, write must take it.  errors from
+// either become faults, so a non-nil error is always a *Fault
+func drive(ctx context.Context, m stepper, read func() error, write func() error) error {
+	done := ctx.Done()
+	for i := 0; ; i++ {
+		if done != nil && i%cancelCheckInterval == 0 {
+			select {
+			case <-done:
+				return m.fault(ctx.Err())
+			default:
+			}
+		}
+
+		pc := m.PC()
+		status, err := m.Step()
+		switch status {
+		case Halted:
+			return nil
+		case Error:
+			return err
+		case NeedsInput:
+			if err := read(); err == io.EOF {
+				return m.fault(ErrInputClosed)
+			} else if err != nil {
+				return m.fault(err)
+			}
+		case ProducedOutput:
+			if err := write(); err != nil {
+				return m.faultAt(pc, err)
+			}
+		}
+	}
+}
+
+// Run runs the program, reading values from input and sending values to output
+// until the program halts or ctx is done.  output is closed on return if it
+// implements io.Closer.  a nil input reads as closed and a nil output discards
+// values.  inputs and outputs implementing ContextInput or ContextOutput stop
+// waiting when ctx is done.  a non-nil error is always a *Fault
+func (c *Computer) Run(ctx context.Context, input Input, output Output) error {
+
+	if closer, ok := output.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	read := func() error {
+		if input == nil {
+			return io.EOF
+		}
+		value, err := readInput(ctx, input)
+		if err != nil {
+			return err
+		}
+		c.QueueInput(value)
+		return nil
+	}
+	write := func() error {
+		value, _ := c.TakeOutput()
+		if output == nil {
+			return nil
+		}
+		return writeOutput(ctx, output, value)
+	}
+	return drive(ctx, c, read, write)
+}
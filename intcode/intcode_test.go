@@ -0,0 +1,292 @@
+package intcode
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func loadTestImage(tb testing.TB, name string) []int64 {
+	program, err := LoadProgram(filepath.Join("testdata", name))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return program
+}
+
+func loadTestProgram(tb testing.TB, name string) *Computer {
+	return NewFromProgram(loadTestImage(tb, name))
+}
+
+// doubler outputs twice its input, then its input plus one
+const doubler = "3,20,1002,20,2,21,4,21,1001,20,1,21,4,21,99"
+
+// run runs program to completion on input, failing the test on a fault
+func run(t *testing.T, program string, input ...int64) []int64 {
+	t.Helper()
+	output := &SliceOutput{}
+	if err := New(program).Run(context.Background(), NewSliceInput(input...), output); err != nil {
+		t.Fatal(err)
+	}
+	return output.Values
+}
+
+const quine = "109,1,204,-1,1001,100,1,100,1008,100,16,101,1006,101,0,99"
+
+// opcodeTests exercise every standard opcode under each parameter mode
+var opcodeTests = []struct {
+	name    string
+	program string
+	input   []int64
+	want    []int64
+}{
+	{"add position", "1,0,0,0,4,0,99", nil, []int64{2}},
+	{"add immediate", "1101,100,-1,7,4,7,99", nil, []int64{99}},
+	{"mul position", "2,5,6,7,4,7,99", nil, []int64{693}},
+	{"mul immediate", "1102,34915192,34915192,7,4,7,99", nil, []int64{1219070632396864}},
+	{"in out position", "3,0,4,0,99", []int64{42}, []int64{42}},
+	{"out immediate", "104,1125899906842624,99", nil, []int64{1125899906842624}},
+	{"jz position taken", "3,12,6,12,15,1,13,14,13,4,13,99,-1,0,1,9", []int64{0}, []int64{0}},
+	{"jz position not taken", "3,12,6,12,15,1,13,14,13,4,13,99,-1,0,1,9", []int64{5}, []int64{1}},
+	{"jnz immediate taken", "3,3,1105,-1,9,1101,0,0,12,4,12,99,1", []int64{3}, []int64{1}},
+	{"jnz immediate not taken", "3,3,1105,-1,9,1101,0,0,12,4,12,99,1", []int64{0}, []int64{0}},
+	{"lt position", "3,9,7,9,10,9,4,9,99,-1,8", []int64{5}, []int64{1}},
+	{"lt immediate", "3,3,1107,-1,8,3,4,3,99", []int64{9}, []int64{0}},
+	{"eq position", "3,9,8,9,10,9,4,9,99,-1,8", []int64{8}, []int64{1}},
+	{"eq immediate", "3,3,1108,-1,8,3,4,3,99", []int64{7}, []int64{0}},
+	{"relative read", quine, nil, Parse(quine)},
+	{"relative in out", "109,10,203,0,204,0,99", []int64{7}, []int64{7}},
+	{"relative write", "109,20,21101,3,4,1,204,1,99", nil, []int64{7}},
+	{"arb relative", "109,7,209,-1,204,-100,99", nil, []int64{99}},
+}
+
+func TestOpcodes(t *testing.T) {
+	for _, test := range opcodeTests {
+		if got := run(t, test.program, test.input...); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestFaults(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		program string
+		input   []int64
+		want    error
+		pc      int64
+	}{
+		{"immediate write", "11101,1,1,5,99", nil, ErrBadMode, 0},
+		{"immediate input", "103,0,99", []int64{1}, ErrBadMode, 0},
+		{"unknown mode", "301,0,0,0,99", nil, ErrBadMode, 0},
+		{"negative read", "4,0,1,-1,0,0,99", nil, ErrNegativeAddress, 2},
+		{"negative relative write", "109,-5,203,0,99", []int64{1}, ErrNegativeAddress, 2},
+		{"negative pc", "1105,1,-1", nil, ErrNegativeAddress, -1},
+		{"unknown opcode", "104,1,98", nil, ErrUnknownOpcode, 2},
+		{"input closed", "3,10,3,10,99", []int64{1}, ErrInputClosed, 2},
+	} {
+		c := New(test.program)
+		err := c.Run(context.Background(), NewSliceInput(test.input...), nil)
+		var fault *Fault
+		if !errors.Is(err, test.want) || !errors.As(err, &fault) {
+			t.Errorf("%s: got %v, want a fault from %v", test.name, err, test.want)
+			continue
+		}
+		if fault.PC != test.pc || c.PC() != test.pc {
+			t.Errorf("%s: faulted at pc %d and stopped at %d, want %d", test.name, fault.PC, c.PC(), test.pc)
+		}
+	}
+}
+
+func TestSelfModifying(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		program string
+		want    []int64
+	}{
+		// OUT #0 runs, is rewritten to OUT [0], and runs again
+		{"loaded code", "104,0,1006,6,9,99,0,0,0,1101,0,4,0,1101,0,1,6,1105,1,0", []int64{0, 4}},
+		// OUT #7 is built at 100, past the image, and runs.  memory grows
+		// again before it is rewritten to OUT [7] and runs again
+		{"grown code", "1101,0,104,100,1101,0,7,101,1101,0,1105,102,1101,0,1,103,1101,0,23,104," +
+			"1105,1,100,1005,500,38,1101,0,1,500,1101,0,4,100,1105,1,100,0,99", []int64{7, 101}},
+	} {
+		if got := run(t, test.program); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestStep(t *testing.T) {
+	c := New("3,20,4,20,99")
+	for i, want := range []struct {
+		input  []int64
+		status Status
+		pc     int64
+	}{
+		{nil, NeedsInput, 0},
+		{nil, NeedsInput, 0},
+		{[]int64{5}, Running, 2},
+		{nil, ProducedOutput, 4},
+		{nil, Halted, 4},
+	} {
+		c.QueueInput(want.input...)
+		status, err := c.Step()
+		if status != want.status || err != nil || c.PC() != want.pc {
+			t.Fatalf("step %d: got %v, %v at pc %d, want %v at pc %d", i, status, err, c.PC(), want.status, want.pc)
+		}
+	}
+	if c.Executed() != 3 {
+		t.Errorf("executed %d instructions, want 3, as waiting for input executes nothing", c.Executed())
+	}
+	if value, ok := c.TakeOutput(); !ok || value != 5 {
+		t.Errorf("got output %d, %v, want 5", value, ok)
+	}
+	if _, ok := c.TakeOutput(); ok {
+		t.Errorf("output was taken twice")
+	}
+
+	if status, err := New("98").Step(); status != Error || !errors.Is(err, ErrUnknownOpcode) {
+		t.Errorf("got %v, %v, want an error", status, err)
+	}
+}
+
+func TestRunUntil(t *testing.T) {
+	c := New("104,1,104,2,3,20,104,3,99")
+	for i, test := range []struct {
+		stop []Status
+		want Status
+		pc   int64
+	}{
+		// with no stop statuses, anything but Running stops
+		{nil, ProducedOutput, 2},
+		// needing input always stops
+		{[]Status{Halted}, NeedsInput, 4},
+		{[]Status{Halted}, NeedsInput, 4},
+	} {
+		if status, err := c.RunUntil(test.stop...); status != test.want || err != nil || c.PC() != test.pc {
+			t.Fatalf("run %d: got %v, %v at pc %d, want %v at pc %d", i, status, err, c.PC(), test.want, test.pc)
+		}
+	}
+	if got := c.TakeOutputs(); !reflect.DeepEqual(got, []int64{1, 2}) {
+		t.Errorf("got outputs %v, want [1 2]", got)
+	}
+
+	c.QueueInput(7)
+	if status, err := c.RunUntil(Halted); status != Halted || err != nil {
+		t.Fatalf("got %v, %v, want halted", status, err)
+	}
+	if c.Memory(20) != 7 || !reflect.DeepEqual(c.TakeOutputs(), []int64{3}) {
+		t.Errorf("input or output lost")
+	}
+
+	// faults always stop
+	if status, err := New("1105,1,3,98").RunUntil(Halted); status != Error || !errors.Is(err, ErrUnknownOpcode) {
+		t.Errorf("got %v, %v, want an error", status, err)
+	}
+}
+
+func TestStepAfterHalt(t *testing.T) {
+	c := New("99")
+	p := NewProfile(Parse("99"))
+	c.SetTracer(p)
+	for i := 0; i < 3; i++ {
+		if status, err := c.Step(); status != Halted || err != nil {
+			t.Fatalf("step %d: got %v, %v", i, status, err)
+		}
+	}
+	if c.Executed() != 1 || p.Executed != 1 {
+		t.Errorf("HLT executed %d times and traced %d, want once", c.Executed(), p.Executed)
+	}
+	clone := c.Clone()
+	if status, _ := clone.Step(); status != Halted || clone.Executed() != 1 {
+		t.Errorf("clone of a halted program got %v after %d instructions", status, clone.Executed())
+	}
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	c := New(doubler)
+	saved := c.Snapshot()
+
+	for _, test := range []struct {
+		input int64
+		want  []int64
+	}{{5, []int64{10, 6}}, {7, []int64{14, 8}}, {5, []int64{10, 6}}} {
+		c.Restore(saved)
+		if c.PC() != 0 || c.Executed() != 0 || c.Memory(20) != 0 {
+			t.Fatalf("restored to pc %d, executed %d, [20] %d", c.PC(), c.Executed(), c.Memory(20))
+		}
+		c.QueueInput(test.input)
+		if status, err := c.RunUntil(Halted); status != Halted || err != nil {
+			t.Fatalf("got %v, %v", status, err)
+		}
+		if got := c.TakeOutputs(); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("input %d: got %v, want %v", test.input, got, test.want)
+		}
+	}
+}
+
+func TestClone(t *testing.T) {
+	c := New(doubler)
+	c.QueueInput(1)
+	clone := c.Clone()
+
+	clone.SetMemory(20, 99)
+	clone.QueueInput(2)
+	if c.Memory(20) != 0 || !reflect.DeepEqual(c.PendingInput(), []int64{1}) {
+		t.Errorf("clone leaked into the original: [20] %d, input %v", c.Memory(20), c.PendingInput())
+	}
+
+	c.SetMemory(21, 5)
+	c.QueueInput(3)
+	if clone.Memory(21) != 0 || !reflect.DeepEqual(clone.PendingInput(), []int64{1, 2}) {
+		t.Errorf("original leaked into the clone: [21] %d, input %v", clone.Memory(21), clone.PendingInput())
+	}
+
+	// running one leaves the other where it was
+	if status, err := clone.RunUntil(Halted); status != Halted || err != nil {
+		t.Fatalf("got %v, %v", status, err)
+	}
+	if c.PC() != 0 || c.Executed() != 0 || c.Memory(21) != 5 {
+		t.Errorf("running the clone moved the original to pc %d, executed %d, [21] %d", c.PC(), c.Executed(), c.Memory(21))
+	}
+}
+
+// BenchmarkBoost runs the day 9 BOOST program in self-test mode
+func BenchmarkBoost(b *testing.B) {
+	boost := loadTestProgram(b, "boost.txt")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		output := &SliceOutput{}
+		if err := boost.Clone().Run(context.Background(), NewSliceInput(1), output); err != nil {
+			b.Fatal(err)
+		}
+		if len(output.Values) != 1 {
+			b.Fatalf("self-test failed: %v", output.Values)
+		}
+	}
+}
+
+// BenchmarkBeamScan probes the 50x50 area scanned in day 19 part 1, using a
+// fresh drone program for every point
+func BenchmarkBeamScan(b *testing.B) {
+	drone := loadTestProgram(b, "beam.txt")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var pulled int64
+		for y := int64(0); y < 50; y++ {
+			for x := int64(0); x < 50; x++ {
+				output := &SliceOutput{}
+				if err := drone.Clone().Run(context.Background(), NewSliceInput(x, y), output); err != nil {
+					b.Fatal(err)
+				}
+				pulled += output.Values[0]
+			}
+		}
+		if pulled != 231 {
+			b.Fatalf("pulled %d, want 231", pulled)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package intcode
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Input supplies values to the program's input instruction.  Read returns
+// io.EOF once no more values will be supplied
+type Input interface {
+	Read() (int64, error)
+}
+
+// Output receives the values sent by the program's output instruction.
+// if an Output also implements io.Closer, it is closed when Run returns
+type Output interface {
+	Write(value int64) error
+}
+
+// ContextInput is an Input that can stop waiting for a value when a context
+// is done, returning the context's error
+type ContextInput interface {
+	Input
+	ReadContext(ctx context.Context) (int64, error)
+}
+
+// ContextOutput is an Output that can stop waiting to send a value when a
+// context is done, returning the context's error
+type ContextOutput interface {
+	Output
+	WriteContext(ctx context.Context, value int64) error
+}
+
+func readInput(ctx context.Context, input Input) (int64, error) {
+	if ci, ok := input.(ContextInput); ok {
+		return ci.ReadContext(ctx)
+	}
+	return input.Read()
+}
+
+func writeOutput(ctx context.Context, output Output, value int64) error {
+	if co, ok := output.(ContextOutput); ok {
+		return co.WriteContext(ctx, value)
+	}
+	return output.Write(value)
+}
+
+// ChanInput reads values from a channel.  a closed channel reads as io.EOF
+type ChanInput <-chan int64
+
+// Read implements Input
+func (c ChanInput) Read() (int64, error) {
+	value, ok := <-c
+	if !ok {
+		return 0, io.EOF
+	}
+	return value, nil
+}
+
+// ReadContext implements ContextInput
+func (c ChanInput) ReadContext(ctx context.Context) (int64, error) {
+	select {
+	case value, ok := <-c:
+		if !ok {
+			return 0, io.EOF
+		}
+		return value, nil
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+// ChanOutput sends values to a channel, closing it when the program halts
+type ChanOutput chan<- int64
+
+// Write implements Output
+func (c ChanOutput) Write(value int64) error {
+	c <- value
+	return nil
+}
+
+// WriteContext implements ContextOutput
+func (c ChanOutput) WriteContext(ctx context.Context, value int64) error {
+	select {
+	case c <- value:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close closes the underlying channel
+func (c ChanOutput) Close() error {
+	close(c)
+	return nil
+}
+
+// FuncInput calls the func whenever the program needs a value
+type FuncInput func() int64
+
+// Read implements Input
+func (f FuncInput) Read() (int64, error) {
+	return f(), nil
+}
+
+// FuncOutput calls the func with every value the program produces
+type FuncOutput func(value int64)
+
+// Write implements Output
+func (f FuncOutput) Write(value int64) error {
+	f(value)
+	return nil
+}
+
+// SliceInput supplies a fixed list of values, in order
+type SliceInput struct {
+	values []int64
+}
+
+// NewSliceInput returns an input that supplies values, then io.EOF
+func NewSliceInput(values ...int64) *SliceInput {
+	return &SliceInput{values: values}
+}
+
+// Read implements Input
+func (s *SliceInput) Read() (int64, error) {
+	if len(s.values) == 0 {
+		return 0, io.EOF
+	}
+	value := s.values[0]
+	s.values = s.values[1:]
+	return value, nil
+}
+
+// SliceOutput collects every value the program produces
+type SliceOutput struct {
+	Values []int64
+}
+
+// Write implements Output
+func (s *SliceOutput) Write(value int64) error {
+	s.Values = append(s.Values, value)
+	return nil
+}
+
+// ASCIIInput supplies each byte read from a reader as one value
+type ASCIIInput struct {
+	reader *bufio.Reader
+}
+
+// NewASCIIInput returns an input that reads bytes from r
+func NewASCIIInput(r io.Reader) *ASCIIInput {
+	return &ASCIIInput{reader: bufio.NewReader(r)}
+}
+
+// Read implements Input
+func (a *ASCIIInput) Read() (int64, error) {
+	b, err := a.reader.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	return int64(b), nil
+}
+
+// ASCIIOutput writes values in the ascii range to a writer as characters.
+// values outside of that range are written as decimal numbers on their own line
+type ASCIIOutput struct {
+	writer io.Writer
+}
+
+// NewASCIIOutput returns an output that writes characters to w
+func NewASCIIOutput(w io.Writer) *ASCIIOutput {
+	return &ASCIIOutput{writer: w}
+}
+
+// Write implements Output
+func (a *ASCIIOutput) Write(value int64) error {
+	if value >= 0 && value <= 127 {
+		_, err := a.writer.Write([]byte{byte(value)})
+		return err
+	}
+	_, err := fmt.Fprintf(a.writer, "%d\n", value)
+	return err
+}
+
+// LineInput supplies one decimal number per line read from a reader.  blank
+// lines are skipped
+type LineInput struct {
+	scanner *bufio.Scanner
+}
+
+// NewLineInput returns an input that reads numbers from r
+func NewLineInput(r io.Reader) *LineInput {
+	return &LineInput{scanner: bufio.NewScanner(r)}
+}
+
+// Read implements Input
+func (l *LineInput) Read() (int64, error) {
+	for l.scanner.Scan() {
+		line := strings.TrimSpace(l.scanner.Text())
+		if line == "" {
+			continue
+		}
+		return strconv.ParseInt(line, 10, 64)
+	}
+	if err := l.scanner.Err(); err != nil {
+		return 0, err
+	}
+	return 0, io.EOF
+}
+
+// LineOutput writes each value to a writer as a decimal number on its own line
+type LineOutput struct {
+	writer io.Writer
+}
+
+// NewLineOutput returns an output that writes numbers to w
+func NewLineOutput(w io.Writer) *LineOutput {
+	return &LineOutput{writer: w}
+}
+
+// Write implements Output
+func (l *LineOutput) Write(value int64) error {
+	_, err := fmt.Fprintf(l.writer, "%d\n", value)
+	return err
+}
@@ -0,0 +1,117 @@
+package intcode
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestASCIIOutput(t *testing.T) {
+	var b bytes.Buffer
+	out := NewASCIIOutput(&b)
+	for _, value := range []int64{'h', 'i', '\n', 127, 128, -1, 19349722} {
+		if err := out.Write(value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want := "hi\n\x7f128\n-1\n19349722\n"; b.String() != want {
+		t.Errorf("got %q, want %q", b.String(), want)
+	}
+}
+
+func TestASCIIInput(t *testing.T) {
+	output := &SliceOutput{}
+	err := New("3,0,4,0,3,0,4,0,99").Run(context.Background(), NewASCIIInput(strings.NewReader("A\n")), output)
+	if err != nil || !reflect.DeepEqual(output.Values, []int64{'A', '\n'}) {
+		t.Errorf("got %v, %v", output.Values, err)
+	}
+}
+
+func TestLineInput(t *testing.T) {
+	in := NewLineInput(strings.NewReader("1\n\n  -2  \n\n\n3"))
+	var got []int64
+	for {
+		value, err := in.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, value)
+	}
+	if want := []int64{1, -2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if _, err := NewLineInput(strings.NewReader("x\n")).Read(); err == nil {
+		t.Errorf("a line that is not a number read without error")
+	}
+}
+
+func TestLineOutput(t *testing.T) {
+	var b bytes.Buffer
+	out := NewLineOutput(&b)
+	out.Write(1)
+	out.Write(-20)
+	if b.String() != "1\n-20\n" {
+		t.Errorf("got %q", b.String())
+	}
+}
+
+func TestChanInput(t *testing.T) {
+	c := make(chan int64, 2)
+	c <- 5
+	close(c)
+	in := ChanInput(c)
+	if value, err := in.Read(); value != 5 || err != nil {
+		t.Errorf("got %d, %v, want 5", value, err)
+	}
+	if _, err := in.Read(); err != io.EOF {
+		t.Errorf("got %v from a closed channel, want io.EOF", err)
+	}
+	if _, err := in.ReadContext(context.Background()); err != io.EOF {
+		t.Errorf("got %v reading a closed channel with a context, want io.EOF", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := ChanInput(make(chan int64)).ReadContext(ctx); err != context.DeadlineExceeded {
+		t.Errorf("got %v waiting on an empty channel, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestChanOutputClosed(t *testing.T) {
+	// the program echoes until its input channel is closed
+	in := make(chan int64, 2)
+	out := make(chan int64, 2)
+	in <- 1
+	in <- 2
+	close(in)
+	err := New("3,20,4,20,1105,1,0").Run(context.Background(), ChanInput(in), ChanOutput(out))
+	if !errors.Is(err, ErrInputClosed) {
+		t.Fatalf("got %v, want ErrInputClosed", err)
+	}
+	var got []int64
+	for value := range out {
+		got = append(got, value)
+	}
+	if !reflect.DeepEqual(got, []int64{1, 2}) {
+		t.Errorf("got %v, want [1 2]", got)
+	}
+}
+
+func TestFuncIO(t *testing.T) {
+	var got []int64
+	next := int64(0)
+	err := New("3,0,4,0,3,0,4,0,99").Run(context.Background(),
+		FuncInput(func() int64 { next += 10; return next }),
+		FuncOutput(func(value int64) { got = append(got, value) }))
+	if err != nil || !reflect.DeepEqual(got, []int64{10, 20}) {
+		t.Errorf("got %v, %v", got, err)
+	}
+}
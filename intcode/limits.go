@@ -0,0 +1,26 @@
+package intcode
+
+// Limits bounds the resources a program may use.  a zero field means no limit
+type Limits struct {
+	// MaxInstructions is the most instructions the computer will execute,
+	// counting every instruction since it was created
+	MaxInstructions int64
+	// MaxAddress is the highest memory address the program may read or
+	// write, or jump to
+	MaxAddress int64
+}
+
+// SetLimits bounds the program.  exceeding a limit stops it with a fault
+// wrapping ErrInstructionLimit or ErrMemoryLimit
+func (c *Computer) SetLimits(limits Limits) {
+	c.limits = limits
+}
+
+// Limits returns the computer's current limits
+func (c *Computer) Limits() Limits {
+	return c.limits
+}
+
+func (c *Computer) outOfRange(address int64) bool {
+	return c.limits.MaxAddress > 0 && address > c.limits.MaxAddress
+}
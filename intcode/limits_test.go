@@ -0,0 +1,64 @@
+package intcode
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// spin jumps to itself forever
+const spin = "1105,1,0"
+
+func TestLimits(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		program string
+		limits  Limits
+		want    error
+		pc      int64
+	}{
+		{"instructions", spin, Limits{MaxInstructions: 100}, ErrInstructionLimit, 0},
+		{"read", "1,1000,0,0,99", Limits{MaxAddress: 100}, ErrMemoryLimit, 0},
+		{"write", "1101,0,0,1000,99", Limits{MaxAddress: 100}, ErrMemoryLimit, 0},
+		{"relative write", "109,990,21101,0,0,11,99", Limits{MaxAddress: 1000}, ErrMemoryLimit, 2},
+		{"pc", "1105,1,1000", Limits{MaxAddress: 100}, ErrMemoryLimit, 1000},
+		{"within limits", "1101,0,0,100,99", Limits{MaxInstructions: 2, MaxAddress: 100}, nil, 0},
+	} {
+		c := New(test.program)
+		c.SetLimits(test.limits)
+		err := c.Run(context.Background(), nil, nil)
+		if !errors.Is(err, test.want) || (err == nil) != (test.want == nil) {
+			t.Errorf("%s: got %v, want %v", test.name, err, test.want)
+			continue
+		}
+		var fault *Fault
+		if test.want != nil && (!errors.As(err, &fault) || fault.PC != test.pc) {
+			t.Errorf("%s: got %v, want a fault at pc %d", test.name, err, test.pc)
+		}
+	}
+
+	c := New(spin)
+	c.SetLimits(Limits{MaxInstructions: 100})
+	c.Run(context.Background(), nil, nil)
+	if c.Executed() != 100 {
+		t.Errorf("executed %d instructions, want 100", c.Executed())
+	}
+}
+
+func TestRunCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	if err := New(spin).Run(ctx, nil, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := New(spin).Run(ctx, nil, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want context.DeadlineExceeded", err)
+	}
+}
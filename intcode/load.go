@@ -0,0 +1,146 @@
+package intcode
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ErrEmptyProgram is returned when a program has no words
+var ErrEmptyProgram = errors.New("empty program")
+
+// ParseError reports a word of a program that is not an integer
+type ParseError struct {
+	// Offset is the byte offset of the token in the program text, and Line
+	// the line it is on, counting from 1
+	Offset int
+	Line   int
+	Token  string
+	Err    error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d, offset %d: bad word %q: %v", e.Line, e.Offset, e.Token, e.Err)
+}
+
+// Unwrap returns the cause of the error
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// parseWords splits a comma separated program into trimmed tokens and passes
+// each to parse, turning the first failure into a *ParseError
+func parseWords(text string, parse func(token string) error) error {
+	if strings.TrimSpace(text) == "" {
+		return ErrEmptyProgram
+	}
+	offset := 0
+	for _, field := range strings.Split(text, ",") {
+		token := strings.TrimFunc(field, unicode.IsSpace)
+		start := offset + strings.Index(field, token)
+		if err := parse(token); err != nil {
+			if numErr, ok := err.(*strconv.NumError); ok {
+				err = numErr.Err
+			}
+			if token == "" {
+				start, err = offset, errors.New("missing value")
+			}
+			line := strings.Count(text[:start], "\n") + 1
+			return &ParseError{Offset: start, Line: line, Token: token, Err: err}
+		}
+		offset += len(field) + 1
+	}
+	return nil
+}
+
+// ParseProgram reads a comma separated program.  whitespace, including
+// newlines, is allowed around every word
+func ParseProgram(text string) ([]int64, error) {
+	var program []int64
+	err := parseWords(text, func(token string) error {
+		value, err := strconv.ParseInt(token, 10, 64)
+		program = append(program, value)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return program, nil
+}
+
+// Parse is ParseProgram for programs known to be well formed, such as those
+// written into source code.  it panics if the program is malformed
+func Parse(instructions string) []int64 {
+	program, err := ParseProgram(instructions)
+	if err != nil {
+		panic("intcode: Parse: " + err.Error())
+	}
+	return program
+}
+
+// ReadProgram reads a program from r, either as comma separated text or as
+// a binary image.  gzip compressed programs are decompressed
+func ReadProgram(r io.Reader) ([]int64, error) {
+	buffered := bufio.NewReader(r)
+	if magic, err := buffered.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		zr, err := gzip.NewReader(buffered)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		buffered = bufio.NewReader(zr)
+	}
+	if magic, err := buffered.Peek(len(imageMagic)); err == nil && isImage(magic) {
+		return ReadImage(buffered)
+	}
+	contents, err := ioutil.ReadAll(buffered)
+	if err != nil {
+		return nil, err
+	}
+	return ParseProgram(string(contents))
+}
+
+// LoadProgram reads a program from the file at path, or from stdin if path is
+// empty or "-".  errors name the file they come from
+func LoadProgram(path string) ([]int64, error) {
+	if path == "" || path == "-" {
+		program, err := ReadProgram(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("stdin: %w", err)
+		}
+		return program, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	program, err := ReadProgram(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return program, nil
+}
+
+// LoadLocal loads the program in the file name kept beside the calling source
+// file, such as a day's puzzle input, so that it is found whichever directory
+// the binary is run from.  the working directory is only searched when there
+// is no such file beside the source, as when the source is not available
+func LoadLocal(name string) ([]int64, error) {
+	if _, file, _, ok := runtime.Caller(1); ok {
+		local := filepath.Join(filepath.Dir(file), name)
+		if _, err := os.Stat(local); err == nil {
+			name = local
+		}
+	}
+	return LoadProgram(name)
+}
@@ -0,0 +1,102 @@
+package intcode
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestParseProgram(t *testing.T) {
+	program, err := ParseProgram(" 1, 2,\n-3 ,\t4\n\n")
+	if err != nil || !reflect.DeepEqual(program, []int64{1, 2, -3, 4}) {
+		t.Errorf("got %v, %v", program, err)
+	}
+
+	for _, test := range []struct {
+		text   string
+		offset int
+		line   int
+		token  string
+		cause  error
+	}{
+		{"1,2,x3,4", 4, 1, "x3", strconv.ErrSyntax},
+		{"1,2,\n 3,\n  4 4", 11, 3, "4 4", strconv.ErrSyntax},
+		{"1,,2", 2, 1, "", nil},
+		{"1,2,3,\n", 6, 1, "", nil},
+		{"99999999999999999999", 0, 1, "99999999999999999999", strconv.ErrRange},
+	} {
+		_, err := ParseProgram(test.text)
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) {
+			t.Errorf("%q: got %v, want a *ParseError", test.text, err)
+			continue
+		}
+		if parseErr.Offset != test.offset || parseErr.Line != test.line || parseErr.Token != test.token {
+			t.Errorf("%q: got %v, want token %q at offset %d on line %d", test.text, err, test.token, test.offset, test.line)
+		}
+		if test.cause != nil && !errors.Is(err, test.cause) {
+			t.Errorf("%q: got %v, want cause %v", test.text, err, test.cause)
+		}
+	}
+
+	if _, err := ParseProgram(" \n"); err != ErrEmptyProgram {
+		t.Errorf("blank program returned %v", err)
+	}
+}
+
+func TestReadProgramGzip(t *testing.T) {
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	zw.Write([]byte("104,1125899906842624,99\n"))
+	zw.Close()
+
+	program, err := ReadProgram(&compressed)
+	if err != nil || !reflect.DeepEqual(program, []int64{104, 1125899906842624, 99}) {
+		t.Errorf("got %v, %v", program, err)
+	}
+}
+
+func TestLoadLocal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "intcode")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	// a file of the same name in the working directory must not win
+	if err := os.Mkdir("testdata", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile("testdata/boost.txt", []byte("99"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	program, err := LoadLocal("testdata/boost.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(program) == 0 || program[0] != 1102 {
+		t.Errorf("loaded %v, want boost", program)
+	}
+
+	// with nothing beside the source, the working directory is used
+	if err := ioutil.WriteFile("only.txt", []byte("1,2,3"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	program, err = LoadLocal("only.txt")
+	if err != nil || !reflect.DeepEqual(program, []int64{1, 2, 3}) {
+		t.Errorf("got %v, %v, want [1 2 3]", program, err)
+	}
+}
@@ -0,0 +1,84 @@
+package intcode
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrStopped is returned by Send when the machine stops before taking a value
+var ErrStopped = errors.New("machine stopped")
+
+// Machine runs a computer on its own goroutine, exchanging values with it over
+// channels.  a machine always stops: when its program halts or faults, when
+// its context is done, or when it is closed.  every started machine should be
+// closed, or waited on once its output has been read
+type Machine struct {
+	input  chan int64
+	output chan int64
+	cancel context.CancelFunc
+	done   chan struct{}
+	err    error
+}
+
+// Start runs c on a new goroutine until its program halts, it faults, or ctx
+// is done.  values already queued on c are read before the machine's input.
+// c must not be used by the caller while the machine is running
+func Start(ctx context.Context, c *Computer) *Machine {
+	ctx, cancel := context.WithCancel(ctx)
+	m := &Machine{
+		input:  make(chan int64),
+		output: make(chan int64),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go func() {
+		defer close(m.done)
+		defer cancel()
+		m.err = c.Run(ctx, ChanInput(m.input), ChanOutput(m.output))
+	}()
+	return m
+}
+
+// Send passes values to the program one at a time, blocking until it reads
+// each one.  it returns ErrStopped if the machine stops first
+func (m *Machine) Send(values ...int64) error {
+	for _, value := range values {
+		select {
+		case m.input <- value:
+		case <-m.done:
+			return ErrStopped
+		}
+	}
+	return nil
+}
+
+// Output returns the channel the program's output is sent on.  it is closed
+// when the machine stops
+func (m *Machine) Output() <-chan int64 {
+	return m.output
+}
+
+// Done returns a channel that is closed when the machine has stopped
+func (m *Machine) Done() <-chan struct{} {
+	return m.done
+}
+
+// Wait blocks until the machine stops and returns why: nil if the program
+// halted, otherwise the *Fault that stopped it.  the program cannot stop while
+// it is waiting to send output, so read Output until it is closed first, or
+// use Close
+func (m *Machine) Wait() error {
+	<-m.done
+	return m.err
+}
+
+// Close stops the machine if it is still running, discards any output it has
+// not sent, and waits for its goroutine to exit.  it returns the same exit
+// reason as Wait, which for a machine stopped by Close is a fault wrapping
+// context.Canceled.  Close may be called more than once
+func (m *Machine) Close() error {
+	m.cancel()
+	for range m.output {
+	}
+	return m.Wait()
+}
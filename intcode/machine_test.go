@@ -0,0 +1,86 @@
+package intcode
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// waitForGoroutines waits for the goroutine count to fall back to baseline
+func waitForGoroutines(t *testing.T, baseline int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > baseline {
+		if time.Now().After(deadline) {
+			buf := make([]byte, 1<<16)
+			t.Fatalf("%d goroutines running, want %d\n%s", runtime.NumGoroutine(), baseline, buf[:runtime.Stack(buf, true)])
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestMachineCloseDoesNotLeak(t *testing.T) {
+	programs := map[string]string{
+		"blocked on output": "104,1,1105,1,0",
+		"blocked on input":  "3,0,99",
+		"busy":              "1105,1,0",
+		"halted unread":     "104,7,99",
+	}
+	baseline := runtime.NumGoroutine()
+	for name, program := range programs {
+		var machines []*Machine
+		for i := 0; i < 50; i++ {
+			machines = append(machines, Start(context.Background(), New(program)))
+		}
+		for _, m := range machines {
+			err := m.Close()
+			if name == "halted unread" {
+				if err != nil && !errors.Is(err, context.Canceled) {
+					t.Errorf("%s: Close returned %v", name, err)
+				}
+			} else if !errors.Is(err, context.Canceled) {
+				t.Errorf("%s: Close returned %v, want context.Canceled", name, err)
+			}
+		}
+	}
+	waitForGoroutines(t, baseline)
+}
+
+func TestMachineHalts(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+	computer := New("3,0,4,0,3,0,4,0,99")
+	computer.QueueInput(5)
+	m := Start(context.Background(), computer)
+	if value := <-m.Output(); value != 5 {
+		t.Fatalf("got %d, want 5", value)
+	}
+	if err := m.Send(6); err != nil {
+		t.Fatal(err)
+	}
+	if value := <-m.Output(); value != 6 {
+		t.Fatalf("got %d, want 6", value)
+	}
+	if _, ok := <-m.Output(); ok {
+		t.Fatal("output not closed at halt")
+	}
+	if err := m.Wait(); err != nil {
+		t.Fatalf("Wait returned %v", err)
+	}
+	if err := m.Send(7); err != ErrStopped {
+		t.Fatalf("Send after halt returned %v, want ErrStopped", err)
+	}
+	waitForGoroutines(t, baseline)
+}
+
+func TestMachineContext(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	m := Start(ctx, New("3,0,99"))
+	if err := m.Wait(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Wait returned %v, want context.DeadlineExceeded", err)
+	}
+	waitForGoroutines(t, baseline)
+}
@@ -0,0 +1,103 @@
+package intcode
+
+// writes this far past the end of dense memory grow it.  anything further out
+// lands in sparse memory, so that a single far away write does not allocate
+// everything in between
+const denseGrowthLimit = 1 << 16
+
+// memory is an intcode address space.  the loaded image and any addresses
+// near it live in a contiguous slice, far out addresses live in a map.
+// instructions decoded from dense memory are cached until their word is written
+type memory struct {
+	dense  []int64
+	sparse map[int64]int64
+	code   []cachedInstruction
+}
+
+func newMemory(program []int64) memory {
+	m := memory{dense: make([]int64, len(program))}
+	copy(m.dense, program)
+	return m
+}
+
+func (m *memory) get(address int64) int64 {
+	if address >= 0 && address < int64(len(m.dense)) {
+		return m.dense[address]
+	}
+	return m.sparse[address]
+}
+
+func (m *memory) set(address int64, value int64) {
+	if address >= 0 && address < int64(len(m.dense)) {
+		m.dense[address] = value
+		if address < int64(len(m.code)) {
+			m.code[address].valid = false
+		}
+		return
+	}
+	if address >= 0 && address < int64(len(m.dense))+denseGrowthLimit {
+		m.grow(address + 1)
+		m.dense[address] = value
+		return
+	}
+	if m.sparse == nil {
+		m.sparse = make(map[int64]int64)
+	}
+	m.sparse[address] = value
+}
+
+// grow extends dense memory to at least size words, pulling in any sparse
+// values that now fall inside it
+func (m *memory) grow(size int64) {
+	if size <= int64(cap(m.dense)) {
+		m.dense = m.dense[:size]
+	} else {
+		newCap := 2 * int64(cap(m.dense))
+		if newCap < size {
+			newCap = size
+		}
+		dense := make([]int64, size, newCap)
+		copy(dense, m.dense)
+		m.dense = dense
+	}
+	for address, value := range m.sparse {
+		if address >= 0 && address < size {
+			m.dense[address] = value
+			delete(m.sparse, address)
+		}
+	}
+}
+
+// instruction returns the decoded instruction at address
+func (m *memory) instruction(address int64) Instruction {
+	if address < 0 || address >= int64(len(m.dense)) {
+		return Decode(m.get(address))
+	}
+	if address >= int64(len(m.code)) {
+		code := make([]cachedInstruction, len(m.dense))
+		copy(code, m.code)
+		m.code = code
+	}
+	cached := &m.code[address]
+	if !cached.valid {
+		cached.Instruction = Decode(m.dense[address])
+		cached.valid = true
+	}
+	return cached.Instruction
+}
+
+func (m *memory) clone() memory {
+	c := memory{dense: make([]int64, len(m.dense))}
+	copy(c.dense, m.dense)
+	if len(m.code) != 0 {
+		c.code = make([]cachedInstruction, len(m.code))
+		copy(c.code, m.code)
+	}
+	if len(m.sparse) != 0 {
+		c.sparse = make(map[int64]int64, len(m.sparse))
+		for address, value := range m.sparse {
+			c.sparse[address] = value
+		}
+	}
+	return c
+}
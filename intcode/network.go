@@ -0,0 +1,292 @@
+package intcode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNoRoute is returned when a packet is addressed to no machine
+	ErrNoRoute = errors.New("no route to address")
+	// ErrIdle is returned by Network.Run when the network is idle and no
+	// monitor wakes it
+	ErrIdle = errors.New("network idle")
+)
+
+// Packet is a message between machines on a network.  machines send packets
+// as three output values: destination, X and Y
+type Packet struct {
+	Source int64
+	Dest   int64
+	X      int64
+	Y      int64
+}
+
+// Router decides what happens to the packets machines send.  it can pass
+// them to Network.Deliver, hold them, or drop them
+type Router interface {
+	Route(n *Network, p Packet) error
+}
+
+// RouterFunc adapts a func to a Router
+type RouterFunc func(n *Network, p Packet) error
+
+// Route implements Router
+func (f RouterFunc) Route(n *Network, p Packet) error {
+	return f(n, p)
+}
+
+// DirectRouter delivers every packet to the machine it is addressed to
+type DirectRouter struct{}
+
+// Route implements Router
+func (DirectRouter) Route(n *Network, p Packet) error {
+	return n.Deliver(p)
+}
+
+// Monitor is told each time the network goes idle.  it can wake the network
+// by delivering packets
+type Monitor interface {
+	Idle(n *Network) error
+}
+
+// the most instructions a machine executes in one turn, so that a machine
+// that never reads input cannot stall the network
+const defaultQuantum = 1 << 16
+
+// Network runs a number of machines, addressed from 0, that talk in packets.
+// machines are booted with their address as their first input.  reading
+// input when no packet is waiting gives -1.  machines take turns in address
+// order, each running until it waits on input a second time in its turn, so
+// the network behaves the same on every run
+type Network struct {
+	machines []*Computer
+	halted   []bool
+	partial  [][]int64
+	router   Router
+	monitors []Monitor
+	quantum  int64
+
+	rounds    int64
+	sent      int64
+	delivered int64
+	dropped   int64
+}
+
+// NewNetwork boots size machines running program.  a nil router delivers
+// packets directly
+func NewNetwork(program []int64, size int, router Router) *Network {
+	if router == nil {
+		router = DirectRouter{}
+	}
+	n := &Network{
+		router:  router,
+		quantum: defaultQuantum,
+		halted:  make([]bool, size),
+		partial: make([][]int64, size),
+	}
+	boot := NewFromProgram(program)
+	for address := 0; address < size; address++ {
+		c := boot.Clone()
+		c.QueueInput(int64(address))
+		n.machines = append(n.machines, c)
+	}
+	return n
+}
+
+// AddMonitor adds m to the monitors told when the network goes idle
+func (n *Network) AddMonitor(m Monitor) {
+	n.monitors = append(n.monitors, m)
+}
+
+// Size returns the number of machines
+func (n *Network) Size() int {
+	return len(n.machines)
+}
+
+// Machine returns the machine at address, or nil if there is none
+func (n *Network) Machine(address int64) *Computer {
+	if address < 0 || address >= int64(len(n.machines)) {
+		return nil
+	}
+	return n.machines[address]
+}
+
+// Rounds returns the number of rounds run
+func (n *Network) Rounds() int64 {
+	return n.rounds
+}
+
+// Sent returns the number of packets machines have sent
+func (n *Network) Sent() int64 {
+	return n.sent
+}
+
+// Dropped returns the number of packets dropped because the machine they
+// were addressed to had halted
+func (n *Network) Dropped() int64 {
+	return n.dropped
+}
+
+// Deliver queues a packet's X and Y as input to the machine it is addressed
+// to.  packets for a machine that has halted are dropped, as it will never
+// read them
+func (n *Network) Deliver(p Packet) error {
+	c := n.Machine(p.Dest)
+	if c == nil {
+		return fmt.Errorf("%w %d: packet %+v", ErrNoRoute, p.Dest, p)
+	}
+	if n.halted[p.Dest] {
+		n.dropped++
+		return nil
+	}
+	c.QueueInput(p.X, p.Y)
+	n.delivered++
+	return nil
+}
+
+// Round gives every machine that has not halted one turn.  it reports
+// whether the network was idle: no running machine had a packet waiting,
+// sent anything, or ran out its turn without waiting on input
+func (n *Network) Round() (bool, error) {
+	n.rounds++
+	idle := true
+	for address, c := range n.machines {
+		if n.halted[address] {
+			continue
+		}
+		waiting := len(c.PendingInput()) != 0
+		sent, blocked, err := n.turn(int64(address), c)
+		if err != nil {
+			return false, err
+		}
+		if waiting || sent || !blocked {
+			idle = false
+		}
+	}
+	return idle, nil
+}
+
+// turn runs one machine's turn.  blocked is true if the turn ended with the
+// machine waiting on input, or halted
+func (n *Network) turn(address int64, c *Computer) (sent bool, blocked bool, err error) {
+	polled := false
+	for i := int64(0); i < n.quantum; i++ {
+		status, err := c.Step()
+		switch status {
+		case Halted:
+			n.halted[address] = true
+			return sent, true, nil
+		case Error:
+			return sent, false, fmt.Errorf("machine %d: %w", address, err)
+		case NeedsInput:
+			if polled {
+				return sent, true, nil
+			}
+			polled = true
+			c.QueueInput(-1)
+		case ProducedOutput:
+			value, _ := c.TakeOutput()
+			n.partial[address] = append(n.partial[address], value)
+			if len(n.partial[address]) < 3 {
+				continue
+			}
+			words := n.partial[address]
+			n.partial[address] = n.partial[address][:0]
+			sent = true
+			n.sent++
+			p := Packet{Source: address, Dest: words[0], X: words[1], Y: words[2]}
+			if err := n.router.Route(n, p); err != nil {
+				return sent, false, fmt.Errorf("machine %d: %w", address, err)
+			}
+		}
+	}
+	return sent, false, nil
+}
+
+// Run runs rounds until stop reports true, or ctx is done.  each time the
+// network goes idle its monitors are told, and if none of them delivers a
+// packet Run returns ErrIdle.  a nil stop runs until then
+func (n *Network) Run(ctx context.Context, stop func() bool) error {
+	for {
+		if stop != nil && stop() {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		idle, err := n.Round()
+		if err != nil {
+			return err
+		}
+		if !idle {
+			continue
+		}
+		before := n.delivered
+		for _, m := range n.monitors {
+			if err := m.Idle(n); err != nil {
+				return err
+			}
+		}
+		if n.delivered == before {
+			return ErrIdle
+		}
+	}
+}
+
+// NAT is a router and monitor that keeps the last packet sent to its address,
+// and sends it to machine 0 whenever the network goes idle.  other packets go
+// to the next router
+type NAT struct {
+	Address int64
+	Next    Router
+	// Last is the most recent packet sent to the NAT, and Received counts them
+	Last     Packet
+	Received int64
+	// Wakes are the packets the NAT has sent to machine 0, oldest first
+	Wakes []Packet
+}
+
+// NewNAT returns a NAT at address passing other packets to next, or
+// delivering them directly if next is nil.  use it as the network's router
+// and add it as a monitor
+func NewNAT(address int64, next Router) *NAT {
+	if next == nil {
+		next = DirectRouter{}
+	}
+	return &NAT{Address: address, Next: next}
+}
+
+// Route implements Router
+func (nat *NAT) Route(n *Network, p Packet) error {
+	if p.Dest != nat.Address {
+		return nat.Next.Route(n, p)
+	}
+	nat.Last = p
+	nat.Received++
+	return nil
+}
+
+// Idle implements Monitor
+func (nat *NAT) Idle(n *Network) error {
+	if nat.Received == 0 {
+		return nil
+	}
+	wake := Packet{Source: nat.Address, Dest: 0, X: nat.Last.X, Y: nat.Last.Y}
+	nat.Wakes = append(nat.Wakes, wake)
+	return n.Deliver(wake)
+}
+
+// RepeatedWake reports the Y value of the NAT's wake packets once it has sent
+// the same Y to machine 0 twice in a row
+func (nat *NAT) RepeatedWake() (int64, bool) {
+	if len(nat.Wakes) < 2 {
+		return 0, false
+	}
+	last, previous := nat.Wakes[len(nat.Wakes)-1], nat.Wakes[len(nat.Wakes)-2]
+	return last.Y, last.Y == previous.Y
+}
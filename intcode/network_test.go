@@ -0,0 +1,129 @@
+package intcode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// relay passes each packet it receives on to the next address, counting hops
+// in X.  the last machine sends to the NAT at 255
+const relay = `
+	IN -> [addr]
+	ADD [addr], #1 -> [next]
+	EQ [next], #%d -> [t]
+	JZ [t], #loop
+	ADD #255, #0 -> [next]
+loop:
+	IN -> [x]
+	EQ [x], #-1 -> [t]
+	JNZ [t], #loop
+	IN -> [y]
+	ADD [x], #1 -> [x]
+	OUT [next]
+	OUT [x]
+	OUT [y]
+	JZ #0, #loop
+addr: data 0
+next: data 0
+x:    data 0
+y:    data 0
+t:    data 0
+`
+
+func relayProgram(t *testing.T, size int) []int64 {
+	program, err := Assemble(strings.NewReader(fmt.Sprintf(relay, size)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return program
+}
+
+func TestNetworkNAT(t *testing.T) {
+	const size = 50
+	program := relayProgram(t, size)
+
+	var rounds []int64
+	for run := 0; run < 2; run++ {
+		nat := NewNAT(255, nil)
+		n := NewNetwork(program, size, nat)
+		n.AddMonitor(nat)
+		if err := n.Deliver(Packet{Dest: 0, X: 0, Y: 7}); err != nil {
+			t.Fatal(err)
+		}
+		err := n.Run(context.Background(), func() bool {
+			_, repeated := nat.RepeatedWake()
+			return repeated
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []Packet{{Source: 255, X: size, Y: 7}, {Source: 255, X: 2 * size, Y: 7}}
+		if len(nat.Wakes) != 2 || nat.Wakes[0] != want[0] || nat.Wakes[1] != want[1] {
+			t.Fatalf("wakes %+v, want %+v", nat.Wakes, want)
+		}
+		if nat.Last.X != 2*size || nat.Last.Source != size-1 {
+			t.Errorf("last packet %+v", nat.Last)
+		}
+		rounds = append(rounds, n.Rounds())
+	}
+	if rounds[0] != rounds[1] {
+		t.Errorf("runs took %v rounds, want the same", rounds)
+	}
+}
+
+func TestNetworkErrors(t *testing.T) {
+	program := relayProgram(t, 50)
+
+	n := NewNetwork(program, 50, nil)
+	n.Deliver(Packet{Dest: 0})
+	if err := n.Run(context.Background(), nil); !errors.Is(err, ErrNoRoute) {
+		t.Errorf("packet to 255 without a NAT returned %v", err)
+	}
+
+	n = NewNetwork(program, 50, nil)
+	if err := n.Run(context.Background(), nil); err != ErrIdle {
+		t.Errorf("quiet network returned %v, want ErrIdle", err)
+	}
+}
+
+// sender has machine 1 halt at once, while the others send to it in two
+// separate turns and then wait for input forever
+const sender = `
+	IN -> [addr]
+	EQ [addr], #1 -> [t]
+	JNZ [t], #stop
+	OUT #1
+	OUT #5
+	OUT #6
+	IN -> [x]
+	IN -> [x]
+	OUT #1
+	OUT #7
+	OUT #8
+wait:
+	IN -> [x]
+	JZ #0, #wait
+stop:
+	HLT
+addr: data 0
+x:    data 0
+t:    data 0
+`
+
+func TestNetworkHaltedMachine(t *testing.T) {
+	program, err := Assemble(strings.NewReader(sender))
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := NewNetwork(program, 3, nil)
+	if err := n.Run(context.Background(), nil); err != ErrIdle {
+		t.Fatalf("got %v, want ErrIdle", err)
+	}
+	// the first packet from 0 is sent before 1 has had its first turn
+	if n.Sent() != 4 || n.Dropped() != 3 {
+		t.Errorf("sent %d and dropped %d packets, want 4 and 3", n.Sent(), n.Dropped())
+	}
+}
@@ -0,0 +1,176 @@
+package intcode
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Role is how an instruction uses one of its parameters
+type Role uint8
+
+const (
+	// Read parameters supply a value to the instruction
+	Read Role = iota
+	// Write parameters name the address the instruction stores its result in
+	Write
+)
+
+// Handler executes an instruction.  args holds one value per parameter: read
+// parameters arrive loaded, and whatever the handler leaves in write
+// parameters is stored once it returns.  the pc then moves past the
+// instruction, unless the handler called Jump or returned Halted.  an
+// instruction that returns NeedsInput or Error is not executed: nothing is
+// stored and the pc does not move.  errors returned with Error are wrapped in
+// a *Fault if they are not one already
+type Handler func(c *Computer, args []int64) (Status, error)
+
+// Op describes an opcode.  read parameters always come before write parameters
+type Op struct {
+	Opcode   int64
+	Mnemonic string
+	Params   []Role
+	Exec     Handler
+	reads    int64
+}
+
+// Size returns the number of words the instruction occupies
+func (o Op) Size() int64 {
+	return int64(len(o.Params)) + 1
+}
+
+// Reads returns the number of read parameters
+func (o Op) Reads() int64 {
+	return o.reads
+}
+
+// the most parameters an instruction can have, as instruction words only
+// have mode digits for three
+const maxParams = 3
+
+// opTable is indexed by opcode.  entries without a mnemonic are unknown opcodes
+type opTable [100]Op
+
+// standardOps holds the opcodes every intcode machine has
+var standardOps = opTable{
+	1:  {Opcode: 1, Mnemonic: "ADD", Params: []Role{Read, Read, Write}, Exec: execAdd},
+	2:  {Opcode: 2, Mnemonic: "MUL", Params: []Role{Read, Read, Write}, Exec: execMul},
+	3:  {Opcode: 3, Mnemonic: "IN", Params: []Role{Write}, Exec: execIn},
+	4:  {Opcode: 4, Mnemonic: "OUT", Params: []Role{Read}, Exec: execOut},
+	5:  {Opcode: 5, Mnemonic: "JNZ", Params: []Role{Read, Read}, Exec: execJNZ},
+	6:  {Opcode: 6, Mnemonic: "JZ", Params: []Role{Read, Read}, Exec: execJZ},
+	7:  {Opcode: 7, Mnemonic: "LT", Params: []Role{Read, Read, Write}, Exec: execLT},
+	8:  {Opcode: 8, Mnemonic: "EQ", Params: []Role{Read, Read, Write}, Exec: execEQ},
+	9:  {Opcode: 9, Mnemonic: "ARB", Params: []Role{Read}, Exec: execARB},
+	99: {Opcode: 99, Mnemonic: "HLT", Exec: execHLT},
+}
+
+var (
+	// ops holds the *opTable in use, standard opcodes and extensions.  it is
+	// never changed in place: Register and Unregister store a changed copy,
+	// so computers can look ops up while they are being registered
+	ops atomic.Value
+	// opsLock serialises Register and Unregister
+	opsLock sync.Mutex
+)
+
+func init() {
+	for i := range standardOps {
+		standardOps[i].reads = countReads(standardOps[i].Params)
+	}
+	table := standardOps
+	ops.Store(&table)
+}
+
+func currentOps() *opTable {
+	return ops.Load().(*opTable)
+}
+
+func countReads(params []Role) int64 {
+	reads := int64(0)
+	for _, role := range params {
+		if role == Read {
+			reads++
+		}
+	}
+	return reads
+}
+
+// Register adds an extension opcode to the instruction set shared by every
+// computer, the assembler and the disassembler.  the opcode must be unused
+// and below 100, the mnemonic unused, and there can be at most three
+// parameters with every read before any write.  it is safe to call while
+// computers are running, which see the new opcode from their next
+// instruction.  BigComputer only runs the standard opcodes
+func Register(op Op) error {
+	opsLock.Lock()
+	defer opsLock.Unlock()
+
+	if op.Opcode < 0 || op.Opcode >= int64(len(standardOps)) {
+		return fmt.Errorf("register opcode %d: opcodes must be 0-%d", op.Opcode, len(standardOps)-1)
+	}
+	if existing, ok := LookupOp(op.Opcode); ok {
+		return fmt.Errorf("register opcode %d: already defined as %s", op.Opcode, existing.Mnemonic)
+	}
+	if op.Mnemonic == "" || op.Mnemonic != strings.ToUpper(op.Mnemonic) || strings.ContainsAny(op.Mnemonic, " \t,;:#[]") {
+		return fmt.Errorf("register opcode %d: mnemonic %q must be upper case with no spaces or punctuation", op.Opcode, op.Mnemonic)
+	}
+	if existing, ok := LookupMnemonic(op.Mnemonic); ok {
+		return fmt.Errorf("register opcode %d: mnemonic %s already used by opcode %d", op.Opcode, op.Mnemonic, existing.Opcode)
+	}
+	if op.Exec == nil {
+		return fmt.Errorf("register opcode %d: no handler", op.Opcode)
+	}
+	if len(op.Params) > maxParams {
+		return fmt.Errorf("register opcode %d: %d parameters, at most %d allowed", op.Opcode, len(op.Params), maxParams)
+	}
+	op.Params = append([]Role(nil), op.Params...)
+	op.reads = countReads(op.Params)
+	for i, role := range op.Params {
+		if role == Read && int64(i) >= op.reads {
+			return fmt.Errorf("register opcode %d: read parameters must come before write parameters", op.Opcode)
+		}
+	}
+	table := *currentOps()
+	table[op.Opcode] = op
+	ops.Store(&table)
+	return nil
+}
+
+// Unregister removes an extension opcode added by Register.  the standard
+// opcodes cannot be removed
+func Unregister(opcode int64) error {
+	opsLock.Lock()
+	defer opsLock.Unlock()
+
+	if isStandard(opcode) {
+		return fmt.Errorf("unregister opcode %d: standard opcodes cannot be removed", opcode)
+	}
+	if _, ok := LookupOp(opcode); !ok {
+		return fmt.Errorf("unregister opcode %d: not registered", opcode)
+	}
+	table := *currentOps()
+	table[opcode] = Op{}
+	ops.Store(&table)
+	return nil
+}
+
+// LookupOp returns the description of opcode
+func LookupOp(opcode int64) (Op, bool) {
+	table := currentOps()
+	if opcode < 0 || opcode >= int64(len(table)) || table[opcode].Mnemonic == "" {
+		return Op{}, false
+	}
+	return table[opcode], true
+}
+
+// LookupMnemonic returns the description of the opcode with the given mnemonic
+func LookupMnemonic(mnemonic string) (Op, bool) {
+	for _, op := range currentOps() {
+		if op.Mnemonic != "" && op.Mnemonic == mnemonic {
+			return op, true
+		}
+	}
+	return Op{}, false
+}
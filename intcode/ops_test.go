@@ -0,0 +1,139 @@
+package intcode
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+var debugLog bytes.Buffer
+
+// sum3 is an extension with three read parameters, more than any standard
+// opcode reads
+var sum3 = Op{
+	Opcode:   52,
+	Mnemonic: "SUM",
+	Params:   []Role{Read, Read, Read},
+	Exec: func(c *Computer, args []int64) (Status, error) {
+		c.QueueInput(args[0] + args[1] + args[2])
+		return Running, nil
+	},
+}
+
+// registerExtensions installs the example extensions at opcodes 50 and 51,
+// and sum3 at 52.  the returned func removes them again
+func registerExtensions(t *testing.T) func() {
+	var registered []int64
+	unregister := func() {
+		for _, opcode := range registered {
+			if err := Unregister(opcode); err != nil {
+				t.Error(err)
+			}
+		}
+	}
+	for _, op := range []Op{DebugPrint(50, &debugLog), HaltWithCode(51), sum3} {
+		if err := Register(op); err != nil {
+			unregister()
+			t.Fatal(err)
+		}
+		registered = append(registered, op.Opcode)
+	}
+	return unregister
+}
+
+func TestExtensionOpcodes(t *testing.T) {
+	defer registerExtensions(t)()
+	debugLog.Reset()
+
+	program, err := Assemble(strings.NewReader(`
+		ADD #2, #3 -> [total]
+		DBG [total]
+		EXIT [total]
+	total: data 0
+	`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = NewFromProgram(program).Run(context.Background(), nil, nil)
+	var exit *ExitError
+	if !errors.As(err, &exit) || exit.Code != 5 {
+		t.Fatalf("got %v, want exit code 5", err)
+	}
+	if got := debugLog.String(); got != "debug pc(4): 5\n" {
+		t.Errorf("debug log %q", got)
+	}
+	if got := Disassemble(program)[1].String(); got != "DBG [8]" {
+		t.Errorf("disassembled %q", got)
+	}
+
+	if err := New("11151,0,99").Run(context.Background(), nil, nil); err != nil {
+		t.Errorf("EXIT #0 returned %v", err)
+	}
+	if err := New("97,0,99").Run(context.Background(), nil, nil); !errors.Is(err, ErrUnknownOpcode) {
+		t.Errorf("unregistered opcode returned %v", err)
+	}
+}
+
+func TestRegisterRejects(t *testing.T) {
+	defer registerExtensions(t)()
+	exec := func(c *Computer, args []int64) (Status, error) { return Running, nil }
+	for _, op := range []Op{
+		{Opcode: 1, Mnemonic: "NEWADD", Exec: exec},
+		{Opcode: 100, Mnemonic: "BIG", Exec: exec},
+		{Opcode: 60, Mnemonic: "ADD", Exec: exec},
+		{Opcode: 60, Mnemonic: "NOP"},
+		{Opcode: 60, Mnemonic: "WIDE", Params: []Role{Read, Read, Read, Read}, Exec: exec},
+		{Opcode: 60, Mnemonic: "BACK", Params: []Role{Write, Read}, Exec: exec},
+		{Opcode: 50, Mnemonic: "AGAIN", Exec: exec},
+	} {
+		if err := Register(op); err == nil {
+			t.Errorf("registered %+v", op)
+		}
+	}
+}
+
+func TestUnregister(t *testing.T) {
+	unregister := registerExtensions(t)
+	unregister()
+	for _, opcode := range []int64{50, 51, 52} {
+		if op, ok := LookupOp(opcode); ok {
+			t.Errorf("%s is still registered", op.Mnemonic)
+		}
+	}
+	if _, ok := LookupMnemonic("EXIT"); ok {
+		t.Errorf("EXIT is still registered")
+	}
+	if err := New("11151,0,99").Run(context.Background(), nil, nil); !errors.Is(err, ErrUnknownOpcode) {
+		t.Errorf("unregistered opcode returned %v", err)
+	}
+	if err := Unregister(50); err == nil {
+		t.Errorf("unregistered an opcode twice")
+	}
+	if err := Unregister(1); err == nil {
+		t.Errorf("unregistered ADD")
+	}
+}
+
+func TestRegisterWhileRunning(t *testing.T) {
+	boost := loadTestProgram(t, "boost.txt")
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				if err := boost.Clone().Run(context.Background(), NewSliceInput(1), nil); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < 20; i++ {
+		registerExtensions(t)()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,27 @@
+package intcode
+
+import "math"
+
+// SetOverflowCheck turns checked arithmetic on or off.  when on, an add or
+// multiply whose result does not fit in an int64 faults with ErrOverflow
+// instead of wrapping.  programs that need the true result can be run on a
+// BigComputer
+func (c *Computer) SetOverflowCheck(check bool) {
+	c.checked = check
+}
+
+// addOverflows reports whether sum, the wrapped result of a+b, overflowed
+func addOverflows(a int64, b int64, sum int64) bool {
+	return (a >= 0) == (b >= 0) && (sum >= 0) != (a >= 0)
+}
+
+// mulOverflows reports whether product, the wrapped result of a*b, overflowed
+func mulOverflows(a int64, b int64, product int64) bool {
+	if a == 0 || b == 0 {
+		return false
+	}
+	if (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return true
+	}
+	return product/b != a
+}
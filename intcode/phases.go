@@ -0,0 +1,88 @@
+package intcode
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// Permutations returns every ordering of length distinct values taken from
+// min to max inclusive, in lexicographic order.  for amplifiers these are
+// the candidate phase settings
+func Permutations(min int64, max int64, length int) [][]int64 {
+	if max < min {
+		return nil
+	}
+	var all [][]int64
+	if length < 0 || int64(length) > max-min+1 {
+		return all
+	}
+	used := make([]bool, max-min+1)
+	current := make([]int64, 0, length)
+
+	var extend func()
+	extend = func() {
+		if len(current) == length {
+			all = append(all, append([]int64(nil), current...))
+			return
+		}
+		for value := min; value <= max; value++ {
+			if used[value-min] {
+				continue
+			}
+			used[value-min] = true
+			current = append(current, value)
+			extend()
+			current = current[:len(current)-1]
+			used[value-min] = false
+		}
+	}
+	extend()
+	return all
+}
+
+// MaxSignal evaluates every candidate setting across a pool of GOMAXPROCS
+// workers and returns the one with the highest signal.  ties go to the
+// candidate that comes first, so the result does not depend on scheduling.
+// evaluate must be safe to call concurrently.  if any evaluation fails, the
+// error for the first failing candidate is returned
+func MaxSignal(candidates [][]int64, evaluate func(setting []int64) (int64, error)) ([]int64, int64, error) {
+	if len(candidates) == 0 {
+		return nil, 0, fmt.Errorf("no candidate settings")
+	}
+
+	signals := make([]int64, len(candidates))
+	errs := make([]error, len(candidates))
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	workers := runtime.GOMAXPROCS(0)
+	if workers > len(candidates) {
+		workers = len(candidates)
+	}
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				signals[i], errs[i] = evaluate(candidates[i])
+			}
+		}()
+	}
+	for i := range candidates {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	best := 0
+	for i := range candidates {
+		if errs[i] != nil {
+			return nil, 0, fmt.Errorf("setting %v: %w", candidates[i], errs[i])
+		}
+		if signals[i] > signals[best] {
+			best = i
+		}
+	}
+	return candidates[best], signals[best], nil
+}
@@ -0,0 +1,51 @@
+package intcode
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestPermutations(t *testing.T) {
+	got := Permutations(1, 3, 2)
+	want := [][]int64{{1, 2}, {1, 3}, {2, 1}, {2, 3}, {3, 1}, {3, 2}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if n := len(Permutations(5, 9, 5)); n != 120 {
+		t.Errorf("got %d settings of 5 amplifiers, want 120", n)
+	}
+	if n := len(Permutations(0, 2, 4)); n != 0 {
+		t.Errorf("got %d settings longer than the digit range", n)
+	}
+	if got := Permutations(5, 2, 0); got != nil {
+		t.Errorf("got %v from an empty digit range", got)
+	}
+}
+
+func TestMaxSignalTies(t *testing.T) {
+	candidates := Permutations(0, 4, 3)
+	// every setting starting with 3 scores the same; the first of them must win
+	for run := 0; run < 20; run++ {
+		setting, signal, err := MaxSignal(candidates, func(setting []int64) (int64, error) {
+			if setting[0] == 3 {
+				return 100, nil
+			}
+			return setting[1], nil
+		})
+		if err != nil || signal != 100 || !reflect.DeepEqual(setting, []int64{3, 0, 1}) {
+			t.Fatalf("got %v %d %v, want [3 0 1] 100", setting, signal, err)
+		}
+	}
+
+	failure := errors.New("boom")
+	_, _, err := MaxSignal(candidates, func(setting []int64) (int64, error) {
+		if setting[0] >= 2 {
+			return 0, failure
+		}
+		return 0, nil
+	})
+	if !errors.Is(err, failure) || err.Error() != "setting [2 0 1]: boom" {
+		t.Errorf("got %v", err)
+	}
+}
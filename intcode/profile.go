@@ -0,0 +1,311 @@
+package intcode
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+)
+
+// Profile counts what a program does as it runs.  attach it with SetTracer
+type Profile struct {
+	program    []int64
+	Executed   int64
+	Executions map[int64]int64
+	Opcodes    map[string]int64
+	Reads      map[int64]int64
+	Writes     map[int64]int64
+}
+
+// NewProfile returns an empty profile for program.  the program is used to
+// produce coverage listings, and should be the image the computer was loaded with
+func NewProfile(program []int64) *Profile {
+	return &Profile{
+		program:    program,
+		Executions: make(map[int64]int64),
+		Opcodes:    make(map[string]int64),
+		Reads:      make(map[int64]int64),
+		Writes:     make(map[int64]int64),
+	}
+}
+
+// Trace implements Tracer.  immediate parameters are part of the instruction,
+// so they are not counted as memory reads
+func (p *Profile) Trace(event TraceEvent) {
+	p.Executed++
+	p.Executions[event.PC]++
+	p.Opcodes[event.Mnemonic]++
+	for _, access := range event.Reads {
+		if access.Mode != Immediate {
+			p.Reads[access.Address]++
+		}
+	}
+	for _, access := range event.Writes {
+		p.Writes[access.Address]++
+	}
+}
+
+type keyCount struct {
+	Key   string
+	Count int64
+}
+
+// byCount sorts counts high to low, then by key
+func byCount(counts map[string]int64) []keyCount {
+	var sorted []keyCount
+	for key, count := range counts {
+		sorted = append(sorted, keyCount{Key: key, Count: count})
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Count != sorted[j].Count {
+			return sorted[i].Count > sorted[j].Count
+		}
+		return sorted[i].Key < sorted[j].Key
+	})
+	return sorted
+}
+
+type addressCount struct {
+	Address int64
+	Count   int64
+}
+
+func hottest(counts map[int64]int64, n int) []addressCount {
+	var sorted []addressCount
+	for address, count := range counts {
+		sorted = append(sorted, addressCount{Address: address, Count: count})
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Count != sorted[j].Count {
+			return sorted[i].Count > sorted[j].Count
+		}
+		return sorted[i].Address < sorted[j].Address
+	})
+	if len(sorted) > n {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
+
+// CoverageLine is a line of the program listing and how often it executed
+type CoverageLine struct {
+	Line
+	Count int64
+}
+
+// Covered reports whether the line is an instruction that executed
+func (c CoverageLine) Covered() bool {
+	return !c.Data && c.Count != 0
+}
+
+// Coverage returns the disassembled program with execution counts, and how
+// many of its instructions executed at least once
+func (p *Profile) Coverage() (lines []CoverageLine, covered int, total int) {
+	for _, line := range Disassemble(p.program) {
+		c := CoverageLine{Line: line, Count: p.Executions[line.Address]}
+		if !line.Data {
+			total++
+			if c.Count != 0 {
+				covered++
+			}
+		}
+		lines = append(lines, c)
+	}
+	return lines, covered, total
+}
+
+func percent(part int64, whole int64) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return 100 * float64(part) / float64(whole)
+}
+
+// the number of addresses shown in each hot list
+const hotListSize = 20
+
+// WriteText writes a plain text report: opcode histogram, hottest
+// instructions, most accessed memory, then an annotated coverage listing in
+// which instructions that never executed are marked with "!!"
+func (p *Profile) WriteText(w io.Writer) error {
+	lines, covered, total := p.Coverage()
+	ew := &errWriter{w: w}
+
+	ew.printf("instructions executed: %d\n", p.Executed)
+	ew.printf("coverage: %d of %d instructions (%.1f%%)\n", covered, total, percent(int64(covered), int64(total)))
+
+	ew.printf("\nopcodes\n")
+	for _, c := range byCount(p.Opcodes) {
+		ew.printf("  %-4s %12d  %5.1f%%\n", c.Key, c.Count, percent(c.Count, p.Executed))
+	}
+
+	ew.printf("\nhottest instructions\n")
+	for _, hot := range hottest(p.Executions, hotListSize) {
+		ew.printf("  %6d %12d  %s\n", hot.Address, hot.Count, p.disassemble(hot.Address))
+	}
+
+	for _, heat := range []struct {
+		title  string
+		counts map[int64]int64
+	}{{"most read addresses", p.Reads}, {"most written addresses", p.Writes}} {
+		ew.printf("\n%s\n", heat.title)
+		for _, hot := range hottest(heat.counts, hotListSize) {
+			ew.printf("  %6d %12d\n", hot.Address, hot.Count)
+		}
+	}
+
+	ew.printf("\ncoverage\n")
+	for _, line := range lines {
+		marker := "  "
+		if !line.Data && line.Count == 0 {
+			marker = "!!"
+		}
+		ew.printf("%s %6d %12d  %s\n", marker, line.Address, line.Count, line.Line)
+	}
+	return ew.err
+}
+
+// disassemble renders the instruction at address in the original program
+func (p *Profile) disassemble(address int64) Line {
+	fetch := func(a int64) int64 {
+		if a < 0 || a >= int64(len(p.program)) {
+			return 0
+		}
+		return p.program[a]
+	}
+	if line, ok := decodeAt(fetch, address, int64(len(p.program))); ok {
+		return line
+	}
+	return Line{Address: address, Words: []int64{fetch(address)}, Data: true}
+}
+
+// errWriter remembers the first write error so reports can be written
+// without checking every line
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (e *errWriter) printf(format string, args ...interface{}) {
+	if e.err == nil {
+		_, e.err = fmt.Fprintf(e.w, format, args...)
+	}
+}
+
+// the number of memory cells on each row of the html heatmap, and the most
+// cells it shows.  far out addresses are left to the text report
+const (
+	heatmapWidth = 32
+	heatmapLimit = 1 << 14
+)
+
+type heatCell struct {
+	Address int64
+	Reads   int64
+	Writes  int64
+	Heat    int
+}
+
+type htmlLine struct {
+	CoverageLine
+	Heat int
+}
+
+// heat scales count against max into a 0-100 intensity
+func heat(count int64, max int64) int {
+	if max == 0 {
+		return 0
+	}
+	return int(100 * count / max)
+}
+
+// WriteHTML writes the report as a standalone html page.  listing rows are
+// shaded by how hot they are, and unexecuted instructions are highlighted
+func (p *Profile) WriteHTML(w io.Writer) error {
+	lines, covered, total := p.Coverage()
+
+	var hottestCount int64
+	for _, count := range p.Executions {
+		if count > hottestCount {
+			hottestCount = count
+		}
+	}
+	var listing []htmlLine
+	for _, line := range lines {
+		listing = append(listing, htmlLine{CoverageLine: line, Heat: heat(line.Count, hottestCount)})
+	}
+
+	// the heatmap covers the loaded image and anything the program touched past it
+	size := int64(len(p.program))
+	var busiest int64
+	for _, counts := range []map[int64]int64{p.Reads, p.Writes} {
+		for address := range counts {
+			if address >= size && address < heatmapLimit {
+				size = address + 1
+			}
+		}
+	}
+	for address := int64(0); address < size; address++ {
+		if count := p.Reads[address] + p.Writes[address]; count > busiest {
+			busiest = count
+		}
+	}
+	var rows [][]heatCell
+	for start := int64(0); start < size; start += heatmapWidth {
+		var row []heatCell
+		for address := start; address < start+heatmapWidth && address < size; address++ {
+			reads, writes := p.Reads[address], p.Writes[address]
+			row = append(row, heatCell{Address: address, Reads: reads, Writes: writes, Heat: heat(reads+writes, busiest)})
+		}
+		rows = append(rows, row)
+	}
+
+	return profileTemplate.Execute(w, map[string]interface{}{
+		"Executed": p.Executed,
+		"Covered":  covered,
+		"Total":    total,
+		"Percent":  fmt.Sprintf("%.1f", percent(int64(covered), int64(total))),
+		"Opcodes":  byCount(p.Opcodes),
+		"Listing":  listing,
+		"Heatmap":  rows,
+	})
+}
+
+var profileTemplate = template.Must(template.New("profile").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>intcode profile</title>
+<style>
+body { font-family: monospace; }
+table { border-collapse: collapse; }
+td, th { padding: 0 0.6em; text-align: right; }
+td.text { text-align: left; }
+tr.uncovered { background: #f4b6b6; }
+tr.data { color: #888; }
+table.heatmap td { width: 1.2em; height: 1.2em; padding: 0; border: 1px solid #eee; }
+</style>
+</head>
+<body>
+<h1>intcode profile</h1>
+<p>{{.Executed}} instructions executed.  coverage: {{.Covered}} of {{.Total}} instructions ({{.Percent}}%)</p>
+
+<h2>opcodes</h2>
+<table>
+<tr><th>opcode</th><th>count</th></tr>
+{{range .Opcodes}}<tr><td class="text">{{.Key}}</td><td>{{.Count}}</td></tr>
+{{end}}</table>
+
+<h2>memory heatmap</h2>
+<table class="heatmap">
+{{range .Heatmap}}<tr>{{range .}}<td title="{{.Address}}: {{.Reads}} reads, {{.Writes}} writes" style="background: rgba(220, 60, 0, {{.Heat}}%)"></td>{{end}}</tr>
+{{end}}</table>
+
+<h2>coverage</h2>
+<table>
+<tr><th>address</th><th>count</th><th class="text">instruction</th></tr>
+{{range .Listing}}<tr class="{{if .Data}}data{{else if not .Covered}}uncovered{{end}}" style="{{if .Covered}}background: rgba(255, 140, 0, {{.Heat}}%){{end}}"><td>{{.Address}}</td><td>{{.Count}}</td><td class="text">{{.Line}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
@@ -0,0 +1,97 @@
+package intcode
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+)
+
+// countdown loops three times and halts, never reaching the output after it
+const countdown = "1101,0,3,20,1001,20,-1,20,1005,20,4,99,104,7,99"
+
+func profileCountdown(t *testing.T) *Profile {
+	t.Helper()
+	program := Parse(countdown)
+	p := NewProfile(program)
+	c := NewFromProgram(program)
+	c.SetTracer(p)
+	if err := c.Run(context.Background(), nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestProfileCounts(t *testing.T) {
+	p := profileCountdown(t)
+
+	if p.Executed != 8 {
+		t.Errorf("executed %d instructions, want 8", p.Executed)
+	}
+	if want := map[string]int64{"ADD": 4, "JNZ": 3, "HLT": 1}; !reflect.DeepEqual(p.Opcodes, want) {
+		t.Errorf("opcodes %v, want %v", p.Opcodes, want)
+	}
+	if want := map[int64]int64{0: 1, 4: 3, 8: 3, 11: 1}; !reflect.DeepEqual(p.Executions, want) {
+		t.Errorf("executions %v, want %v", p.Executions, want)
+	}
+	if want := map[int64]int64{20: 6}; !reflect.DeepEqual(p.Reads, want) {
+		t.Errorf("reads %v, want %v", p.Reads, want)
+	}
+	if want := map[int64]int64{20: 4}; !reflect.DeepEqual(p.Writes, want) {
+		t.Errorf("writes %v, want %v", p.Writes, want)
+	}
+
+	lines, covered, total := p.Coverage()
+	if covered != 4 || total != 6 {
+		t.Errorf("coverage %d of %d, want 4 of 6", covered, total)
+	}
+	var uncovered []int64
+	for _, line := range lines {
+		if !line.Data && !line.Covered() {
+			uncovered = append(uncovered, line.Address)
+		}
+	}
+	if want := []int64{12, 14}; !reflect.DeepEqual(uncovered, want) {
+		t.Errorf("uncovered instructions at %v, want %v", uncovered, want)
+	}
+}
+
+const countdownText = `instructions executed: 8
+coverage: 4 of 6 instructions (66.7%)
+
+opcodes
+  ADD             4   50.0%
+  JNZ             3   37.5%
+  HLT             1   12.5%
+
+hottest instructions
+       4            3  ADD [20], #-1 -> [20]
+       8            3  JNZ [20], #4
+       0            1  ADD #0, #3 -> [20]
+      11            1  HLT
+
+most read addresses
+      20            6
+
+most written addresses
+      20            4
+
+coverage
+        0            1  ADD #0, #3 -> [20]
+        4            3  ADD [20], #-1 -> [20]
+        8            3  JNZ [20], #4
+       11            1  HLT
+!!     12            0  OUT #7
+!!     14            0  HLT
+`
+
+func TestProfileWriteText(t *testing.T) {
+	p := profileCountdown(t)
+	var b bytes.Buffer
+	if err := p.WriteText(&b); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != countdownText {
+		t.Errorf("got report\n%s\nwant\n%s", b.String(), countdownText)
+	}
+}
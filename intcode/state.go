@@ -0,0 +1,70 @@
+package intcode
+
+// State is a saved copy of a computer's registers, memory and queued io
+type State struct {
+	pc           int64
+	relativeBase int64
+	memory       memory
+	inputs       []int64
+	outputs      []int64
+	executed     int64
+	halted       bool
+}
+
+// PC returns the saved program counter
+func (s *State) PC() int64 {
+	return s.pc
+}
+
+// RelativeBase returns the saved relative base
+func (s *State) RelativeBase() int64 {
+	return s.relativeBase
+}
+
+func copyValues(values []int64) []int64 {
+	if len(values) == 0 {
+		return nil
+	}
+	return append([]int64(nil), values...)
+}
+
+// Snapshot saves the computer's current state
+func (c *Computer) Snapshot() *State {
+	return &State{
+		pc:           c.pc,
+		relativeBase: c.relativeBase,
+		memory:       c.memory.clone(),
+		inputs:       copyValues(c.inputs),
+		outputs:      copyValues(c.outputs),
+		executed:     c.executed,
+		halted:       c.halted,
+	}
+}
+
+// Restore returns the computer to a saved state.  the state is left
+// untouched, so it can be restored any number of times
+func (c *Computer) Restore(s *State) {
+	c.pc = s.pc
+	c.relativeBase = s.relativeBase
+	c.memory = s.memory.clone()
+	c.inputs = copyValues(s.inputs)
+	c.outputs = copyValues(s.outputs)
+	c.executed = s.executed
+	c.halted = s.halted
+}
+
+// Clone returns an independent copy of the computer.  running either one
+// does not affect the other.  the clone is not traced
+func (c *Computer) Clone() *Computer {
+	return &Computer{
+		pc:           c.pc,
+		relativeBase: c.relativeBase,
+		memory:       c.memory.clone(),
+		limits:       c.limits,
+		checked:      c.checked,
+		inputs:       copyValues(c.inputs),
+		outputs:      copyValues(c.outputs),
+		executed:     c.executed,
+		halted:       c.halted,
+	}
+}
@@ -0,0 +1,105 @@
+package intcode
+
+import (
+	"bufio"
+	"context"
+	"io"
+)
+
+// Terminal talks to a program that reads and writes ascii text.  output in
+// the ascii range is streamed to a writer as characters, and anything else,
+// such as a final answer, is kept as a result
+type Terminal struct {
+	computer *Computer
+	out      io.Writer
+	results  []int64
+}
+
+// NewTerminal returns a terminal for c writing the program's text to out
+func NewTerminal(c *Computer, out io.Writer) *Terminal {
+	return &Terminal{computer: c, out: out}
+}
+
+// Send queues text as input, one value per byte
+func (t *Terminal) Send(text string) {
+	for i := 0; i < len(text); i++ {
+		t.computer.QueueInput(int64(text[i]))
+	}
+}
+
+// SendLine queues line followed by a newline
+func (t *Terminal) SendLine(line string) {
+	t.Send(line)
+	t.Send("\n")
+}
+
+// Results returns every value the program has output outside the ascii range
+func (t *Terminal) Results() []int64 {
+	return t.results
+}
+
+// Result returns the most recent result
+func (t *Terminal) Result() (int64, bool) {
+	if len(t.results) == 0 {
+		return 0, false
+	}
+	return t.results[len(t.results)-1], true
+}
+
+// Run runs the program until it halts, or needs input that has not been sent,
+// and returns which.  a non-nil error is always a *Fault
+func (t *Terminal) Run(ctx context.Context) (Status, error) {
+	done := ctx.Done()
+	for i := 0; ; i++ {
+		if done != nil && i%cancelCheckInterval == 0 {
+			select {
+			case <-done:
+				return Error, t.computer.fault(ctx.Err())
+			default:
+			}
+		}
+
+		status, err := t.computer.Step()
+		switch status {
+		case Halted, NeedsInput:
+			return status, nil
+		case Error:
+			return status, err
+		case ProducedOutput:
+			value, _ := t.computer.TakeOutput()
+			if value < 0 || value > 127 {
+				t.results = append(t.results, value)
+				continue
+			}
+			if _, err := t.out.Write([]byte{byte(value)}); err != nil {
+				return Error, t.computer.fault(err)
+			}
+		}
+	}
+}
+
+// Interact runs the program, sending it a line read from in each time it
+// needs input, until it halts.  results are passed to result as they are
+// produced, if it is not nil
+func (t *Terminal) Interact(ctx context.Context, in io.Reader, result func(value int64)) error {
+	lines := bufio.NewScanner(in)
+	for {
+		seen := len(t.results)
+		status, err := t.Run(ctx)
+		if result != nil {
+			for _, value := range t.results[seen:] {
+				result(value)
+			}
+		}
+		if status == Halted || err != nil {
+			return err
+		}
+		if !lines.Scan() {
+			if err := lines.Err(); err != nil {
+				return t.computer.fault(err)
+			}
+			return t.computer.fault(ErrInputClosed)
+		}
+		t.SendLine(lines.Text())
+	}
+}
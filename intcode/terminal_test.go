@@ -0,0 +1,103 @@
+package intcode
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// counter prompts for lines and echoes them, then outputs each line's length
+// plus 1000 as a result.  a line starting with q halts it
+const counter = `
+start:
+	OUT #62
+	OUT #10
+loop:
+	IN -> [c]
+	EQ [c], #113 -> [t]
+	JNZ [t], #stop
+	EQ [c], #10 -> [t]
+	JNZ [t], #eol
+	OUT [c]
+	ADD [n], #1 -> [n]
+	JZ #0, #loop
+eol:
+	ADD [n], #1000 -> [n]
+	OUT [n]
+	ADD #0, #0 -> [n]
+	JZ #0, #start
+stop:
+	HLT
+c: data 0
+t: data 0
+n: data 0
+`
+
+func newCounter(t *testing.T, out *bytes.Buffer) *Terminal {
+	t.Helper()
+	program, err := Assemble(strings.NewReader(counter))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewTerminal(NewFromProgram(program), out)
+}
+
+func TestTerminalRun(t *testing.T) {
+	var out bytes.Buffer
+	term := newCounter(t, &out)
+
+	term.SendLine("hello")
+	status, err := term.Run(context.Background())
+	if status != NeedsInput || err != nil {
+		t.Fatalf("got %v, %v, want needs input", status, err)
+	}
+	if out.String() != ">\nhello>\n" {
+		t.Errorf("got text %q", out.String())
+	}
+	if result, ok := term.Result(); !ok || result != 1005 {
+		t.Errorf("got result %d, %v, want 1005", result, ok)
+	}
+
+	term.Send("q")
+	if status, err := term.Run(context.Background()); status != Halted || err != nil {
+		t.Fatalf("got %v, %v, want halted", status, err)
+	}
+	if !reflect.DeepEqual(term.Results(), []int64{1005}) {
+		t.Errorf("got results %v, want [1005]", term.Results())
+	}
+}
+
+func TestTerminalInteract(t *testing.T) {
+	var out bytes.Buffer
+	term := newCounter(t, &out)
+
+	var results []int64
+	err := term.Interact(context.Background(), strings.NewReader("ab\ncde\nq\n"), func(value int64) {
+		results = append(results, value)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != ">\nab>\ncde>\n" {
+		t.Errorf("got text %q", out.String())
+	}
+	if !reflect.DeepEqual(results, []int64{1002, 1003}) {
+		t.Errorf("got results %v, want [1002 1003]", results)
+	}
+}
+
+func TestTerminalInputClosed(t *testing.T) {
+	var out bytes.Buffer
+	term := newCounter(t, &out)
+
+	err := term.Interact(context.Background(), strings.NewReader("ab\n"), nil)
+	if !errors.Is(err, ErrInputClosed) {
+		t.Fatalf("got %v, want ErrInputClosed", err)
+	}
+	if !reflect.DeepEqual(term.Results(), []int64{1002}) {
+		t.Errorf("got results %v, want [1002]", term.Results())
+	}
+}
@@ -0,0 +1,214 @@
+package intcode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrDeadlock is returned when every machine in a topology that has not
+// halted is waiting for input that will never come
+var ErrDeadlock = errors.New("topology deadlocked")
+
+// Topology wires machines together by their input and output.  every value a
+// node outputs is sent to each node it is connected to, so one node can feed
+// many, and values from many nodes are merged in the order they are sent.
+// outputs of nodes with no connections are collected as the final output.
+// nodes take turns in the order they were added, so a topology behaves the
+// same on every run
+type Topology struct {
+	nodes []*topologyNode
+	index map[string]*topologyNode
+}
+
+type topologyNode struct {
+	name     string
+	computer *Computer
+	next     []*topologyNode
+	received int64
+	sent     int64
+	last     int64
+	halted   bool
+}
+
+// NodeStats describes what a node did during Run
+type NodeStats struct {
+	Name     string
+	Executed int64
+	Received int64
+	Sent     int64
+	// Last is the last value the node sent, if it sent any
+	Last   int64
+	Halted bool
+}
+
+// Result is what a topology produced
+type Result struct {
+	// Outputs holds every value sent by each node with no outgoing connections
+	Outputs map[string][]int64
+	// Stats holds each node's statistics, in the order the nodes were added
+	Stats []NodeStats
+}
+
+// Node returns the statistics for the named node
+func (r *Result) Node(name string) (NodeStats, bool) {
+	for _, stats := range r.Stats {
+		if stats.Name == name {
+			return stats, true
+		}
+	}
+	return NodeStats{}, false
+}
+
+// NewTopology returns an empty topology
+func NewTopology() *Topology {
+	return &Topology{index: make(map[string]*topologyNode)}
+}
+
+// AddNode adds a machine running a copy of program.  initial values, such as
+// a phase setting, are its first inputs
+func (t *Topology) AddNode(name string, program []int64, initial ...int64) error {
+	if _, ok := t.index[name]; ok {
+		return fmt.Errorf("node %q already added", name)
+	}
+	node := &topologyNode{name: name, computer: NewFromProgram(program)}
+	node.computer.QueueInput(initial...)
+	node.received = int64(len(initial))
+	t.nodes = append(t.nodes, node)
+	t.index[name] = node
+	return nil
+}
+
+// Input queues values on a node after its initial inputs, such as the
+// signal that starts a chain
+func (t *Topology) Input(name string, values ...int64) error {
+	node, ok := t.index[name]
+	if !ok {
+		return fmt.Errorf("no node %q", name)
+	}
+	node.computer.QueueInput(values...)
+	node.received += int64(len(values))
+	return nil
+}
+
+// Connect sends the output of from to the input of to
+func (t *Topology) Connect(from string, to string) error {
+	source, ok := t.index[from]
+	if !ok {
+		return fmt.Errorf("no node %q", from)
+	}
+	dest, ok := t.index[to]
+	if !ok {
+		return fmt.Errorf("no node %q", to)
+	}
+	source.next = append(source.next, dest)
+	return nil
+}
+
+// Chain connects each named node to the one after it
+func (t *Topology) Chain(names ...string) error {
+	for i := 1; i < len(names); i++ {
+		if err := t.Connect(names[i-1], names[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Ring chains the named nodes and connects the last back to the first
+func (t *Topology) Ring(names ...string) error {
+	if err := t.Chain(names...); err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	return t.Connect(names[len(names)-1], names[0])
+}
+
+// Run runs every node until all have halted, returning what they produced.
+// values sent to a halted node are dropped.  it fails if a node faults, ctx
+// is done, or the nodes still running are all waiting for input.  a topology
+// can only be run once
+func (t *Topology) Run(ctx context.Context) (*Result, error) {
+	result := &Result{Outputs: make(map[string][]int64)}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+
+		progressed, running := false, false
+		for _, node := range t.nodes {
+			if node.halted {
+				continue
+			}
+			executed := node.computer.Executed()
+			if err := t.turn(node, result); err != nil {
+				return nil, err
+			}
+			if node.computer.Executed() != executed {
+				progressed = true
+			}
+			if !node.halted {
+				running = true
+			}
+		}
+		if !running {
+			break
+		}
+		if !progressed {
+			var waiting []string
+			for _, node := range t.nodes {
+				if !node.halted {
+					waiting = append(waiting, node.name)
+				}
+			}
+			return nil, fmt.Errorf("%w: %s waiting for input", ErrDeadlock, strings.Join(waiting, ", "))
+		}
+	}
+
+	for _, node := range t.nodes {
+		result.Stats = append(result.Stats, NodeStats{
+			Name:     node.name,
+			Executed: node.computer.Executed(),
+			Received: node.received,
+			Sent:     node.sent,
+			Last:     node.last,
+			Halted:   node.halted,
+		})
+	}
+	return result, nil
+}
+
+// turn runs a node until it halts, waits for input, or has run for a quantum
+func (t *Topology) turn(node *topologyNode, result *Result) error {
+	for i := 0; i < defaultQuantum; i++ {
+		status, err := node.computer.Step()
+		switch status {
+		case Halted:
+			node.halted = true
+			return nil
+		case NeedsInput:
+			return nil
+		case Error:
+			return fmt.Errorf("node %s: %w", node.name, err)
+		case ProducedOutput:
+			value, _ := node.computer.TakeOutput()
+			node.sent++
+			node.last = value
+			if len(node.next) == 0 {
+				result.Outputs[node.name] = append(result.Outputs[node.name], value)
+			}
+			for _, next := range node.next {
+				if !next.halted {
+					next.computer.QueueInput(value)
+					next.received++
+				}
+			}
+		}
+	}
+	return nil
+}
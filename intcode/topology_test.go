@@ -0,0 +1,59 @@
+package intcode
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestTopologyFanOutFanIn(t *testing.T) {
+	source := Parse("104,5,104,6,99")
+	double := Parse("3,9,1002,9,2,9,4,9,99,0")
+	sum := Parse("3,11,3,12,1,11,12,13,4,13,99,0,0,0")
+
+	topology := NewTopology()
+	for _, node := range []struct {
+		name    string
+		program []int64
+	}{{"source", source}, {"left", double}, {"right", double}, {"sum", sum}} {
+		if err := topology.AddNode(node.name, node.program); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, edge := range [][2]string{{"source", "left"}, {"source", "right"}, {"left", "sum"}, {"right", "sum"}} {
+		if err := topology.Connect(edge[0], edge[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := topology.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := result.Outputs["sum"]; len(got) != 1 || got[0] != 20 {
+		t.Errorf("sum output %v, want [20]", got)
+	}
+	// each double reads only the first of the source's two values
+	if left, _ := result.Node("left"); left.Received != 2 || left.Sent != 1 || left.Last != 10 || !left.Halted {
+		t.Errorf("left stats %+v", left)
+	}
+	if s, _ := result.Node("source"); s.Sent != 2 || s.Executed != 3 {
+		t.Errorf("source stats %+v", s)
+	}
+}
+
+func TestTopologyDeadlock(t *testing.T) {
+	topology := NewTopology()
+	topology.AddNode("a", Parse("3,0,99"))
+	topology.AddNode("b", Parse("3,0,4,0,99"))
+	topology.Ring("a", "b")
+	if _, err := topology.Run(context.Background()); !errors.Is(err, ErrDeadlock) {
+		t.Errorf("got %v, want ErrDeadlock", err)
+	}
+	if err := topology.AddNode("a", nil); err == nil {
+		t.Error("added a duplicate node")
+	}
+	if err := topology.Connect("a", "c"); err == nil {
+		t.Error("connected to a missing node")
+	}
+}
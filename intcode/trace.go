@@ -0,0 +1,142 @@
+package intcode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// ErrDiverged is returned by Replay when a program does not repeat its trace
+var ErrDiverged = errors.New("execution diverged from trace")
+
+// Access is one memory access made by an instruction parameter.  for
+// immediate parameters the address is that of the parameter itself
+type Access struct {
+	Mode    Mode  `json:"mode"`
+	Operand int64 `json:"operand"`
+	Address int64 `json:"addr"`
+	Value   int64 `json:"value"`
+}
+
+// TraceEvent records one executed instruction
+type TraceEvent struct {
+	Step         int64    `json:"n"`
+	PC           int64    `json:"pc"`
+	RelativeBase int64    `json:"rb"`
+	Instruction  int64    `json:"inst"`
+	Mnemonic     string   `json:"op"`
+	Reads        []Access `json:"reads,omitempty"`
+	Writes       []Access `json:"writes,omitempty"`
+	Input        *int64   `json:"in,omitempty"`
+	Output       *int64   `json:"out,omitempty"`
+}
+
+// Tracer receives an event for every instruction the computer executes.
+// instructions that fault, or wait for input, are not executed
+type Tracer interface {
+	Trace(event TraceEvent)
+}
+
+// SetTracer starts sending trace events to t.  a nil t stops tracing
+func (c *Computer) SetTracer(t Tracer) {
+	c.tracer = t
+	c.tracing = c.tracer != nil || c.history != nil
+}
+
+func (c *Computer) traceAccess(inst Instruction, pcOffset int64, address int64, value int64, role Role) {
+	access := Access{
+		Mode:    inst.Modes[pcOffset-1],
+		Operand: c.memory.get(c.pc + pcOffset),
+		Address: address,
+		Value:   value,
+	}
+	if role == Read {
+		c.event.Reads = append(c.event.Reads, access)
+	} else {
+		c.event.Writes = append(c.event.Writes, access)
+	}
+}
+
+// JSONTracer writes each event as a line of json
+type JSONTracer struct {
+	encoder *json.Encoder
+	err     error
+}
+
+// NewJSONTracer returns a tracer writing json lines to w
+func NewJSONTracer(w io.Writer) *JSONTracer {
+	return &JSONTracer{encoder: json.NewEncoder(w)}
+}
+
+// Trace implements Tracer
+func (t *JSONTracer) Trace(event TraceEvent) {
+	if t.err == nil {
+		t.err = t.encoder.Encode(event)
+	}
+}
+
+// Err returns the first error encountered writing the trace
+func (t *JSONTracer) Err() error {
+	return t.err
+}
+
+// lastEvent keeps only the most recent event
+type lastEvent struct {
+	event TraceEvent
+	seen  bool
+}
+
+func (l *lastEvent) Trace(event TraceEvent) {
+	l.event = event
+	l.seen = true
+}
+
+// Replay runs program again, feeding it the inputs recorded in a json lines
+// trace, and checks that every instruction it executes matches the trace.
+// it returns the number of instructions replayed
+func Replay(program []int64, trace io.Reader) (int64, error) {
+	_, _, replayed, err := replay(program, trace)
+	return replayed, err
+}
+
+// replay is Replay, also returning the computer in its final state and the
+// values it output
+func replay(program []int64, trace io.Reader) (*Computer, []int64, int64, error) {
+	c := NewFromProgram(program)
+	last := &lastEvent{}
+	c.SetTracer(last)
+
+	decoder := json.NewDecoder(trace)
+	var outputs []int64
+	replayed := int64(0)
+	for {
+		var recorded TraceEvent
+		if err := decoder.Decode(&recorded); err == io.EOF {
+			return c, outputs, replayed, nil
+		} else if err != nil {
+			return c, outputs, replayed, err
+		}
+
+		if recorded.Input != nil {
+			c.QueueInput(*recorded.Input)
+		}
+		last.seen = false
+		status, err := c.Step()
+		if status == Error {
+			return c, outputs, replayed, fmt.Errorf("%w at step %d: %v", ErrDiverged, recorded.Step, err)
+		}
+		if !last.seen {
+			return c, outputs, replayed, fmt.Errorf("%w at step %d: program stopped with status %v", ErrDiverged, recorded.Step, status)
+		}
+		outputs = append(outputs, c.TakeOutputs()...)
+
+		if !reflect.DeepEqual(recorded, last.event) {
+			want, _ := json.Marshal(recorded)
+			got, _ := json.Marshal(last.event)
+			return c, outputs, replayed, fmt.Errorf("%w at step %d:\n  recorded %s\n  replayed %s", ErrDiverged, recorded.Step, want, got)
+		}
+		replayed++
+	}
+}
@@ -0,0 +1,65 @@
+package intcode
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// record runs program on input, returning its json lines trace, outputs and
+// final state
+func record(t *testing.T, program []int64, input ...int64) (*bytes.Buffer, []int64, *Computer) {
+	t.Helper()
+	var trace bytes.Buffer
+	tracer := NewJSONTracer(&trace)
+	c := NewFromProgram(program)
+	c.SetTracer(tracer)
+	output := &SliceOutput{}
+	if err := c.Run(context.Background(), NewSliceInput(input...), output); err != nil {
+		t.Fatal(err)
+	}
+	if err := tracer.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return &trace, output.Values, c
+}
+
+func TestReplay(t *testing.T) {
+	program := loadTestImage(t, "boost.txt")
+	trace, outputs, original := record(t, program, 1)
+
+	c, replayedOutputs, replayed, err := replay(program, trace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replayed != original.executed {
+		t.Errorf("replayed %d instructions, want %d", replayed, original.executed)
+	}
+	if !reflect.DeepEqual(replayedOutputs, outputs) {
+		t.Errorf("replay output %v, want %v", replayedOutputs, outputs)
+	}
+	if !reflect.DeepEqual(c.Core(nil), original.Core(nil)) {
+		t.Errorf("replay finished in a different state")
+	}
+}
+
+func TestReplayDiverged(t *testing.T) {
+	program := Parse(doubler)
+	trace, _, _ := record(t, program, 5)
+
+	// multiplying by 3 rather than 2 changes the second instruction
+	changed := append([]int64(nil), program...)
+	changed[4] = 3
+	if _, err := Replay(changed, bytes.NewReader(trace.Bytes())); !errors.Is(err, ErrDiverged) || !strings.Contains(err.Error(), "at step 2:") {
+		t.Errorf("changed program gave %v, want divergence at step 2", err)
+	}
+
+	// a different input is stored differently by the first instruction
+	edited := strings.Replace(trace.String(), `"in":5`, `"in":6`, 1)
+	if _, err := Replay(program, strings.NewReader(edited)); !errors.Is(err, ErrDiverged) || !strings.Contains(err.Error(), "at step 1:") {
+		t.Errorf("changed input gave %v, want divergence at step 1", err)
+	}
+}